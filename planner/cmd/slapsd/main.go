@@ -2,25 +2,223 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
 
 	execapp "github.com/james/tasks-planner/internal/app/exec"
+	"github.com/james/tasks-planner/internal/httpapi"
+	m "github.com/james/tasks-planner/internal/model"
+	telemetrypkg "github.com/james/tasks-planner/internal/telemetry"
 )
 
 func main() {
 	coordPath := flag.String("coord", "./coordinator.json", "Path to coordinator.json artifact")
+	workerCmd := flag.String(
+		"worker-cmd",
+		"",
+		"Shell command run once per task; receives the task JSON on stdin and TASKS_TASK_ID in the environment. Non-zero exit marks the task failed.",
+	)
+	checkDir := flag.String("check-dir", "", "Working directory for task acceptance checks (defaults to the current directory)")
+	profile := flag.String("profile", "", "Resource profile from config.resources.profiles (e.g. local, ci, prod) overlaid onto catalog capacities")
+	ledgerPath := flag.String("ledger", "", "Provenance ledger path (default: provenance.jsonl next to --coord; \"-\" disables)")
+	telemetryDir := flag.String("telemetry-dir", "", "Directory for per-task JSONL telemetry (default: telemetry/ next to --coord; \"-\" disables)")
+	breakerPath := flag.String("breaker-state", "", "Circuit breaker state file (default: inside --state-dir, or breakers.json next to --coord when checkpoints are disabled; \"-\" disables outside checkpoints)")
+	stateDir := flag.String("state-dir", "", "Checkpoint directory keyed by coordinator hash (default: .slapsd next to --coord; \"-\" disables)")
+	resume := flag.Bool("resume", false, "Resume the interrupted run checkpointed in --state-dir (refused if the coordinator or --profile changed)")
+	maxCompensations := flag.Int("max-compensations", 0, "Times a failed non-idempotent task whose retry policy allows no retries is rolled back and requeued before failing (default: policies.max_compensations, else 1)")
+	var workers []execapp.WorkerSpec
+	flag.Func("worker", "Local worker running --worker-cmd as id=cap1,cap2 (repeatable); tasks go to a worker with every capability they require", func(v string) error {
+		ws, err := execapp.ParseWorkerSpec(v)
+		if err != nil {
+			return err
+		}
+		workers = append(workers, ws)
+		return nil
+	})
+	patchDir := flag.String("patch-dir", "", "Directory polled for hot patch files (*.json) applied to the running plan; write each under another name and rename it into place")
+	adminAddr := flag.String("admin-addr", "", "Listen address for the admin HTTP API, e.g. 127.0.0.1:7070 (disabled when empty)")
+	adminToken := flag.String("admin-token", os.Getenv("SLAPSD_ADMIN_TOKEN"), "Bearer token required by the admin HTTP API (default: $SLAPSD_ADMIN_TOKEN)")
+	simulate := flag.Bool("simulate", false, "Dry run: schedule --coord on a virtual clock with PERT-sampled durations and print the timeline as JSON")
+	seed := flag.Int64("seed", 1, "Random seed for --simulate duration sampling")
+	simOut := flag.String("sim-out", "", "Write the --simulate timeline to this file instead of stdout")
 	flag.Parse()
 
-	svc := execapp.NewDefaultService()
-	if err := svc.Run(context.Background(), *coordPath); err != nil {
-		if errors.Is(err, execapp.ErrLoopNotImplemented) {
-			fmt.Fprintln(os.Stderr, "slapsd: execution loop not yet implemented (stub)")
+	if *simulate {
+		os.Exit(runSimulation(*coordPath, *profile, *seed, workers, *simOut))
+	}
+	if *workerCmd == "" {
+		fmt.Fprintln(os.Stderr, "slapsd: --worker-cmd is required")
+		os.Exit(1)
+	}
+
+	ledger := *ledgerPath
+	switch ledger {
+	case "":
+		ledger = filepath.Join(filepath.Dir(*coordPath), "provenance.jsonl")
+	case "-":
+		ledger = ""
+	}
+	if *adminAddr != "" && (*adminToken == "" || ledger == "") {
+		fmt.Fprintln(os.Stderr, "slapsd: --admin-addr needs --admin-token and a provenance --ledger to audit writes")
+		os.Exit(1)
+	}
+
+	telemetry := *telemetryDir
+	switch telemetry {
+	case "":
+		telemetry = filepath.Join(filepath.Dir(*coordPath), "telemetry")
+	case "-":
+		telemetry = ""
+	}
+
+	state := *stateDir
+	switch state {
+	case "":
+		state = filepath.Join(filepath.Dir(*coordPath), ".slapsd")
+	case "-":
+		state = ""
+	}
+	if *resume && state == "" {
+		fmt.Fprintln(os.Stderr, "slapsd: --resume needs a --state-dir")
+		os.Exit(1)
+	}
+
+	breakers := *breakerPath
+	switch breakers {
+	case "":
+		if state == "" {
+			breakers = filepath.Join(filepath.Dir(*coordPath), "breakers.json")
+		}
+	case "-":
+		breakers = ""
+	}
+
+	svc := execapp.NewDefaultService(execapp.Config{
+		WorkerCmd:        *workerCmd,
+		Workers:          workers,
+		Profile:          *profile,
+		CheckDir:         *checkDir,
+		LedgerPath:       ledger,
+		TelemetryDir:     telemetry,
+		BreakerPath:      breakers,
+		StateDir:         state,
+		Resume:           *resume,
+		PatchDir:         *patchDir,
+		MaxCompensations: *maxCompensations,
+		OnResume: func(sum execapp.ResumeSummary) {
+			fmt.Fprintf(os.Stderr, "slapsd: resuming from checkpoint %s: %d done, %d interrupted, %d patches\n",
+				sum.Checkpoint, len(sum.Done), len(sum.Interrupted), len(sum.Patches))
+		},
+		OnPatch: func(file string, res execapp.PatchResult, err error) {
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "slapsd: patch %s rejected: %v\n", filepath.Base(file), err)
+				return
+			}
+			fmt.Fprintf(os.Stderr, "slapsd: patch %s applied (graph %s)\n", res.PatchID, res.GraphHash)
+		},
+		OnTelemetryViolation: func(task m.Task, v telemetrypkg.Violation) {
+			fmt.Fprintf(os.Stderr, "slapsd: %s non-conforming telemetry line %d: %s\n", task.ID, v.Line, v.Reason)
+		},
+		OnBreaker: func(st execapp.BreakerState) {
+			if st.Open {
+				fmt.Fprintf(os.Stderr, "slapsd: breaker %s open (%s) until %s after %q from %s\n",
+					st.Name, st.Action, st.OpenUntil.Format(time.RFC3339), st.Fingerprint, st.TaskID)
+				return
+			}
+			fmt.Fprintf(os.Stderr, "slapsd: breaker %s closed\n", st.Name)
+		},
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+		Notify: func(ev execapp.Event) {
+			if ev.Err != nil {
+				fmt.Fprintf(os.Stderr, "slapsd: %s %s: %v\n", ev.TaskID, ev.State, ev.Err)
+				return
+			}
+			if ev.WorkerID != "" && ev.State == execapp.TaskRunning {
+				fmt.Fprintf(os.Stderr, "slapsd: %s %s on %s\n", ev.TaskID, ev.State, ev.WorkerID)
+				return
+			}
+			fmt.Fprintf(os.Stderr, "slapsd: %s %s\n", ev.TaskID, ev.State)
+		},
+	})
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	var admin *http.Server
+	if *adminAddr != "" {
+		ln, err := net.Listen("tcp", *adminAddr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "slapsd: admin: %v\n", err)
 			os.Exit(1)
 		}
+		admin = &http.Server{
+			Handler: httpapi.Admin{
+				Token:      *adminToken,
+				Graph:      svc.Graph,
+				Provenance: svc.Provenance,
+				Breakers:   svc.Breakers,
+				ApplyPatch: svc.ApplyPatch,
+				Audit:      svc.AuditAdmin,
+			}.Handler(),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		go func() {
+			if err := admin.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				fmt.Fprintf(os.Stderr, "slapsd: admin: %v\n", err)
+			}
+		}()
+		fmt.Fprintf(os.Stderr, "slapsd: admin API on http://%s/admin/\n", ln.Addr())
+	}
+
+	err := svc.Run(ctx, *coordPath)
+	if admin != nil {
+		shutdownCtx, stop := context.WithTimeout(context.Background(), 5*time.Second)
+		_ = admin.Shutdown(shutdownCtx)
+		stop()
+	}
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "slapsd: %v\n", err)
 		os.Exit(1)
 	}
 }
+
+func runSimulation(coordPath, profile string, seed int64, workers []execapp.WorkerSpec, out string) int {
+	coord, err := execapp.FilesystemCoordinatorLoader{}.Load(coordPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "slapsd: %v\n", err)
+		return 1
+	}
+	tl, err := execapp.Simulate(coord, execapp.SimOptions{Profile: profile, Seed: seed, Workers: workers})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "slapsd: simulate: %v\n", err)
+		return 1
+	}
+	raw, err := json.MarshalIndent(tl, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "slapsd: simulate: %v\n", err)
+		return 1
+	}
+	raw = append(raw, '\n')
+	if out == "" {
+		_, err = os.Stdout.Write(raw)
+	} else {
+		err = os.WriteFile(out, raw, 0o644)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "slapsd: simulate: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "slapsd: simulated %d tasks in %.2fh on %d workers (utilization %.0f%%, %.2f idle worker-hours)\n",
+		len(tl.Tasks), tl.Makespan, tl.Workers, tl.Utilization*100, tl.IdleWorkerHours)
+	return 0
+}
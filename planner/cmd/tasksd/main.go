@@ -9,15 +9,20 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/james/tasks-planner/internal/acceptance"
 	analysis "github.com/james/tasks-planner/internal/analysis"
 	"github.com/james/tasks-planner/internal/app/plan"
 	"github.com/james/tasks-planner/internal/canonjson"
 	"github.com/james/tasks-planner/internal/export/dot"
+	"github.com/james/tasks-planner/internal/forecast"
 	"github.com/james/tasks-planner/internal/hash"
 	m "github.com/james/tasks-planner/internal/model"
+	dagbuild "github.com/james/tasks-planner/internal/planner/dag"
+	"github.com/james/tasks-planner/internal/provenance"
 	"github.com/james/tasks-planner/internal/validate"
 	validators "github.com/james/tasks-planner/internal/validators"
 )
@@ -25,9 +30,13 @@ import (
 func usage() {
 	fmt.Fprintf(os.Stderr, "tasksd commands:\n")
 	fmt.Fprintf(os.Stderr, "  canonical <file.json>        Canonicalize JSON and print SHA-256.\n")
+	fmt.Fprintf(os.Stderr, "  check --dir DIR [--task ID] [--workdir W]  Run task acceptance checks from tasks.json.\n")
 	fmt.Fprintf(os.Stderr, "  export-dot --dir DIR                  Emit dag.dot/runtime.dot from artifacts in DIR.\n")
 	fmt.Fprintf(os.Stderr, "  export-dot --dag D --tasks T [--out O] Emit DOT from dag.json + tasks.json.\n")
 	fmt.Fprintf(os.Stderr, "  export-dot --coordinator C [--out O]  Emit DOT from coordinator.json.\n")
+	fmt.Fprintf(os.Stderr, "  forecast --dir DIR [--runs N] [--seed S]  Monte Carlo P50/P80/P95 schedule forecast.\n")
+	fmt.Fprintf(os.Stderr, "  ledger verify --ledger FILE [--head HASH]  Verify provenance ledger hash chain.\n")
+	fmt.Fprintf(os.Stderr, "  review-soft --dir DIR                 List soft/low-confidence dependencies for sign-off.\n")
 	fmt.Fprintf(os.Stderr, "  plan [--doc FILE] [--repo DIR] [--out DIR]  Create stub artifacts and DOTs.\n")
 	fmt.Fprintf(os.Stderr, "  validate --dir DIR                    Validate artifacts (hashes + schemas).\n")
 }
@@ -41,10 +50,18 @@ func main() {
 	switch os.Args[1] {
 	case "canonical":
 		runCanonical()
+	case "check":
+		runCheck()
 	case "export-dot", "dot":
 		runExportDot()
+	case "forecast":
+		runForecast()
+	case "ledger":
+		runLedger()
 	case "plan":
 		runPlan()
+	case "review-soft":
+		runReviewSoft()
 	case "validate":
 		runValidate()
 	default:
@@ -251,6 +268,18 @@ func runPlan() {
 		false,
 		"Exit with an error when any validator fails (default logs warnings only).",
 	)
+	qualityGates := fs.String(
+		"quality-gates",
+		plan.QualityGatesWarn,
+		"DAG quality gates (edge density, isolated tasks, verb-first titles, evidence coverage): warn|strict; strict fails the plan.",
+	)
+	repairCycles := fs.Bool(
+		"repair-cycles",
+		false,
+		fmt.Sprintf("Break up to %d cycles through interface hand-offs by inserting contract tasks before failing.", dagbuild.MaxRepairPasses),
+	)
+	forecastRuns := fs.Int("forecast-runs", forecast.DefaultRuns, "Monte Carlo samples behind the P50/P80/P95 estimates.")
+	forecastSeed := fs.Int64("forecast-seed", 1, "Seed for the Monte Carlo estimates (same seed, same artifacts).")
 	_ = fs.Parse(os.Args[2:])
 
 	if err := os.MkdirAll(*out, 0o755); err != nil {
@@ -288,6 +317,10 @@ func runPlan() {
 			Timeout:       *validatorsTimeout,
 		},
 		StrictValidators: *validatorsStrict,
+		QualityGates:     *qualityGates,
+		RepairCycles:     *repairCycles,
+		ForecastRuns:     *forecastRuns,
+		ForecastSeed:     *forecastSeed,
 	}
 
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
@@ -301,12 +334,221 @@ func runPlan() {
 	for _, warn := range res.Warnings {
 		fmt.Fprintf(os.Stderr, "validators warning: %s\n", warn)
 	}
+	for _, warn := range res.QualityWarnings {
+		fmt.Fprintf(os.Stderr, "quality warning: %s\n", warn)
+	}
+	for _, r := range res.CycleRepairs {
+		fmt.Fprintf(os.Stderr, "cycle repair %d: inserted %s for %s; %s -> %s replaced\n", r.Pass, r.Contract, r.Interface, r.Producer, strings.Join(r.Consumers, ", "))
+	}
 
 	fmt.Println("Plan written to", *out)
 }
 
 const validatorDetailLimit = 2048
 
+// -----------------
+// check
+// -----------------
+func runCheck() {
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	dir := fs.String("dir", "", "Directory containing tasks.json")
+	taskID := fs.String("task", "", "Task ID to check (default: every task)")
+	workdir := fs.String("workdir", ".", "Working directory for commands and relative check paths")
+	_ = fs.Parse(os.Args[2:])
+	if *dir == "" {
+		fmt.Fprintln(os.Stderr, "Usage: tasksd check --dir ./plans [--task T003] [--workdir .]")
+		os.Exit(1)
+	}
+
+	var tf m.TasksFile
+	if err := loadJSON(join(*dir, "tasks.json"), &tf); err != nil {
+		fmt.Fprintf(os.Stderr, "check: load tasks.json: %v\n", err)
+		os.Exit(1)
+	}
+	var selected []m.Task
+	for _, t := range tf.Tasks {
+		if *taskID == "" || t.ID == *taskID {
+			selected = append(selected, t)
+		}
+	}
+	if len(selected) == 0 {
+		fmt.Fprintf(os.Stderr, "check: task %s not found in %s\n", *taskID, join(*dir, "tasks.json"))
+		os.Exit(1)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	engine := acceptance.Engine{Dir: *workdir}
+	okAll := true
+	for _, t := range selected {
+		results, err := engine.RunAll(ctx, t.AcceptanceChecks)
+		for _, res := range results {
+			status := "PASS"
+			if !res.Passed {
+				status = "FAIL"
+			}
+			fmt.Printf("%s %s[%d] %s: %s\n", status, t.ID, res.Index, res.Type, res.Detail)
+		}
+		if err != nil {
+			okAll = false
+			if ctx.Err() != nil {
+				fmt.Fprintf(os.Stderr, "check: %v\n", err)
+				break
+			}
+		}
+	}
+	if !okAll {
+		os.Exit(2)
+	}
+}
+
+// -----------------
+// forecast
+// -----------------
+func runForecast() {
+	fs := flag.NewFlagSet("forecast", flag.ExitOnError)
+	dir := fs.String("dir", "", "Directory containing coordinator.json (and optionally waves.json)")
+	runs := fs.Int("runs", forecast.DefaultRuns, "Number of Monte Carlo samples (the plan command's default, so estimates match)")
+	seed := fs.Int64("seed", 1, "Random seed; the same seed always yields the same forecast")
+	_ = fs.Parse(os.Args[2:])
+	if *dir == "" || *runs <= 0 {
+		fmt.Fprintf(os.Stderr, "Usage: tasksd forecast --dir ./plans [--runs %d] [--seed N]\n", forecast.DefaultRuns)
+		os.Exit(1)
+	}
+
+	var coord m.Coordinator
+	if err := loadJSON(join(*dir, "coordinator.json"), &coord); err != nil {
+		fmt.Fprintf(os.Stderr, "forecast: load coordinator.json: %v\n", err)
+		os.Exit(1)
+	}
+	var waves m.WavesArtifact
+	if p := join(*dir, "waves.json"); exists(p) {
+		if err := loadJSON(p, &waves); err != nil {
+			fmt.Fprintf(os.Stderr, "forecast: load waves.json: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	res, err := forecast.Run(coord, waves.Waves, forecast.Options{Runs: *runs, Seed: *seed})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(res); err != nil {
+		fmt.Fprintf(os.Stderr, "forecast: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// -----------------
+// review-soft
+// -----------------
+func runReviewSoft() {
+	fs := flag.NewFlagSet("review-soft", flag.ExitOnError)
+	dir := fs.String("dir", "", "Directory containing dag.json (and tasks.json for titles)")
+	_ = fs.Parse(os.Args[2:])
+	if *dir == "" {
+		fmt.Fprintln(os.Stderr, "Usage: tasksd review-soft --dir ./plans")
+		os.Exit(1)
+	}
+
+	var df m.DagFile
+	if err := loadJSON(join(*dir, "dag.json"), &df); err != nil {
+		fmt.Fprintf(os.Stderr, "review-soft: load dag.json: %v\n", err)
+		os.Exit(1)
+	}
+	titles := map[string]string{}
+	var tf m.TasksFile
+	if p := join(*dir, "tasks.json"); exists(p) {
+		if err := loadJSON(p, &tf); err != nil {
+			fmt.Fprintf(os.Stderr, "review-soft: load tasks.json: %v\n", err)
+			os.Exit(1)
+		}
+		for _, t := range tf.Tasks {
+			titles[t.ID] = t.Title
+		}
+	}
+	fmt.Print(softDepsReport(df, titles))
+}
+
+// softDepsReport lists each soft or low-confidence edge with the reason it was kept out of the
+// DAG and its evidence, so a reviewer can promote or discard it.
+func softDepsReport(df m.DagFile, titles map[string]string) string {
+	var b strings.Builder
+	soft := df.Analysis.SoftDeps
+	if len(soft) == 0 {
+		b.WriteString("No soft or low-confidence dependencies to review.\n")
+		return b.String()
+	}
+	minConf := df.Metrics.MinConfidenceApplied
+	fmt.Fprintf(&b, "%d dependencies need review (min confidence %.2f):\n", len(soft), minConf)
+	label := func(id string) string {
+		if t := titles[id]; t != "" {
+			return fmt.Sprintf("%s (%s)", id, t)
+		}
+		return id
+	}
+	for i, e := range soft {
+		var reasons []string
+		if !e.IsHard {
+			reasons = append(reasons, "soft")
+		}
+		if e.Confidence < minConf {
+			reasons = append(reasons, "low confidence")
+		}
+		fmt.Fprintf(&b, "\n%d. %s -> %s\n", i+1, label(e.From), label(e.To))
+		fmt.Fprintf(&b, "   type %s, confidence %.2f: %s\n", edgeTypeLabel(e), e.Confidence, strings.Join(reasons, ", "))
+		if len(e.Evidence) == 0 {
+			b.WriteString("   evidence: none\n")
+		}
+		for _, ev := range e.Evidence {
+			fmt.Fprintf(&b, "   evidence: [%s] %s", ev.Type, ev.Source)
+			if ev.Excerpt != "" {
+				fmt.Fprintf(&b, " %q", ev.Excerpt)
+			}
+			b.WriteString("\n")
+			if ev.Rationale != "" {
+				fmt.Fprintf(&b, "     rationale: %s\n", ev.Rationale)
+			}
+		}
+	}
+	return b.String()
+}
+
+func edgeTypeLabel(e m.Edge) string {
+	if e.Subtype != "" {
+		return e.Type + "/" + e.Subtype
+	}
+	return e.Type
+}
+
+// -----------------
+// ledger
+// -----------------
+func runLedger() {
+	if len(os.Args) < 3 || os.Args[2] != "verify" {
+		fmt.Fprintln(os.Stderr, "Usage: tasksd ledger verify --ledger ./provenance.jsonl [--head HASH]")
+		os.Exit(1)
+	}
+	fs := flag.NewFlagSet("ledger verify", flag.ExitOnError)
+	path := fs.String("ledger", "./provenance.jsonl", "Path to the provenance ledger")
+	head := fs.String("head", "", "Expected hash of the last record (detects tail truncation)")
+	_ = fs.Parse(os.Args[3:])
+
+	res, err := provenance.VerifyFile(*path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ledger: %v\n", err)
+		os.Exit(2)
+	}
+	if *head != "" && res.Head != *head {
+		fmt.Fprintf(os.Stderr, "ledger: %v: head %s, expected %s\n", provenance.ErrTruncated, res.Head, *head)
+		os.Exit(2)
+	}
+	fmt.Printf("OK %s: %d records, head %s\n", *path, res.Records, res.Head)
+}
+
 // -----------------
 // validate
 // -----------------
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	m "github.com/james/tasks-planner/internal/model"
+)
+
+func TestSoftDepsReport(t *testing.T) {
+	var df m.DagFile
+	df.Metrics.MinConfidenceApplied = 0.7
+	df.Analysis.SoftDeps = []m.Edge{
+		{From: "T001", To: "T002", Type: "technical", IsHard: true, Confidence: 0.5},
+		{From: "T002", To: "T003", Type: "sequential", Subtype: "ordering", Confidence: 0.9,
+			Evidence: []m.Evidence{{Type: "doc", Source: "spec.md:12", Excerpt: "after migration", Rationale: "ordering hint"}}},
+	}
+	out := softDepsReport(df, map[string]string{"T001": "Setup DB"})
+	for _, want := range []string{
+		"2 dependencies need review (min confidence 0.70)",
+		"1. T001 (Setup DB) -> T002",
+		"type technical, confidence 0.50: low confidence",
+		"evidence: none",
+		"type sequential/ordering, confidence 0.90: soft",
+		`evidence: [doc] spec.md:12 "after migration"`,
+		"rationale: ordering hint",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report missing %q:\n%s", want, out)
+		}
+	}
+
+	if out := softDepsReport(m.DagFile{}, nil); !strings.Contains(out, "No soft") {
+		t.Errorf("empty report = %q", out)
+	}
+}
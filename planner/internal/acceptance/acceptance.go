@@ -0,0 +1,362 @@
+package acceptance
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	m "github.com/james/tasks-planner/internal/model"
+)
+
+// Supported AcceptanceCheck.Type values.
+const (
+	TypeCommand      = "command"
+	TypeFileExists   = "file_exists"
+	TypeFileContains = "file_contains"
+	TypeJSONPath     = "json_path"
+)
+
+// DefaultTimeout applies when a check omits timeoutSeconds.
+const DefaultTimeout = 60 * time.Second
+
+// ErrChecksFailed is returned by RunAll when at least one check did not pass.
+var ErrChecksFailed = errors.New("acceptance checks failed")
+
+// ExecFunc runs a shell command in dir and returns stdout, stderr and the exit code.
+// A non-nil error means the command could not be run or was interrupted.
+type ExecFunc func(ctx context.Context, dir, command string) (stdout, stderr []byte, exitCode int, err error)
+
+// Result captures the outcome of a single check.
+type Result struct {
+	Index  int    `json:"index"`
+	Type   string `json:"type"`
+	Passed bool   `json:"passed"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Engine evaluates structured acceptance checks.
+//
+// Expectations per type (all keys optional unless noted):
+//   - command: exit_code (default 0), stdout_contains, stdout_regex, stdout_equals.
+//   - file_exists: exists (default true).
+//   - file_contains: contains or regex (one required).
+//   - json_path: path (required, e.g. "$.meta.version" or "tasks[0].id"), equals, exists (default true).
+type Engine struct {
+	// Dir is the working directory for commands and the base for relative paths.
+	Dir  string
+	Exec ExecFunc
+}
+
+// RunAll evaluates checks in order and returns every result, plus ErrChecksFailed if any failed.
+func (e Engine) RunAll(ctx context.Context, checks []m.AcceptanceCheck) ([]Result, error) {
+	results := make([]Result, 0, len(checks))
+	failed := 0
+	for i, c := range checks {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		res := e.Run(ctx, c)
+		res.Index = i
+		results = append(results, res)
+		if !res.Passed {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return results, fmt.Errorf("%w: %d of %d", ErrChecksFailed, failed, len(checks))
+	}
+	return results, nil
+}
+
+// Run evaluates a single check.
+func (e Engine) Run(ctx context.Context, c m.AcceptanceCheck) Result {
+	timeout := DefaultTimeout
+	if c.Timeout > 0 {
+		timeout = time.Duration(c.Timeout) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var detail string
+	var err error
+	switch strings.TrimSpace(c.Type) {
+	case TypeCommand:
+		detail, err = e.runCommand(ctx, c)
+	case TypeFileExists:
+		detail, err = e.fileExists(c)
+	case TypeFileContains:
+		detail, err = e.fileContains(c)
+	case TypeJSONPath:
+		detail, err = e.jsonPath(c)
+	default:
+		err = fmt.Errorf("unsupported check type %q", c.Type)
+	}
+	res := Result{Type: c.Type, Passed: err == nil, Detail: detail}
+	if err != nil {
+		res.Detail = err.Error()
+	}
+	return res
+}
+
+func (e Engine) runCommand(ctx context.Context, c m.AcceptanceCheck) (string, error) {
+	if strings.TrimSpace(c.Cmd) == "" {
+		return "", errors.New("command check missing cmd")
+	}
+	execFn := e.Exec
+	if execFn == nil {
+		execFn = defaultExec
+	}
+	stdout, stderr, code, err := execFn(ctx, e.Dir, c.Cmd)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return "", fmt.Errorf("command timed out: %s", c.Cmd)
+		}
+		return "", fmt.Errorf("command %q: %w", c.Cmd, err)
+	}
+	wantCode := 0
+	if v, ok := c.Expect["exit_code"]; ok {
+		n, ok := asInt(v)
+		if !ok {
+			return "", fmt.Errorf("expect.exit_code must be an integer, got %v", v)
+		}
+		wantCode = n
+	}
+	if code != wantCode {
+		msg := fmt.Sprintf("exit code %d, want %d", code, wantCode)
+		if tail := strings.TrimSpace(string(stderr)); tail != "" {
+			msg += ": " + tail
+		}
+		return "", errors.New(msg)
+	}
+	out := string(stdout)
+	if want, ok := c.Expect["stdout_contains"].(string); ok && !strings.Contains(out, want) {
+		return "", fmt.Errorf("stdout does not contain %q", want)
+	}
+	if want, ok := c.Expect["stdout_equals"].(string); ok && strings.TrimSpace(out) != strings.TrimSpace(want) {
+		return "", fmt.Errorf("stdout %q does not equal %q", strings.TrimSpace(out), want)
+	}
+	if pattern, ok := c.Expect["stdout_regex"].(string); ok {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return "", fmt.Errorf("expect.stdout_regex: %w", err)
+		}
+		if !re.MatchString(out) {
+			return "", fmt.Errorf("stdout does not match %q", pattern)
+		}
+	}
+	return fmt.Sprintf("exit code %d", code), nil
+}
+
+func (e Engine) fileExists(c m.AcceptanceCheck) (string, error) {
+	path, err := e.resolve(c.Path)
+	if err != nil {
+		return "", err
+	}
+	want := true
+	if v, ok := c.Expect["exists"].(bool); ok {
+		want = v
+	}
+	_, statErr := os.Stat(path)
+	exists := statErr == nil
+	if statErr != nil && !os.IsNotExist(statErr) {
+		return "", statErr
+	}
+	if exists != want {
+		if want {
+			return "", fmt.Errorf("%s does not exist", c.Path)
+		}
+		return "", fmt.Errorf("%s exists", c.Path)
+	}
+	return fmt.Sprintf("%s exists=%t", c.Path, exists), nil
+}
+
+func (e Engine) fileContains(c m.AcceptanceCheck) (string, error) {
+	path, err := e.resolve(c.Path)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	if want, ok := c.Expect["contains"].(string); ok {
+		if !strings.Contains(string(data), want) {
+			return "", fmt.Errorf("%s does not contain %q", c.Path, want)
+		}
+		return fmt.Sprintf("%s contains %q", c.Path, want), nil
+	}
+	if pattern, ok := c.Expect["regex"].(string); ok {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return "", fmt.Errorf("expect.regex: %w", err)
+		}
+		if !re.Match(data) {
+			return "", fmt.Errorf("%s does not match %q", c.Path, pattern)
+		}
+		return fmt.Sprintf("%s matches %q", c.Path, pattern), nil
+	}
+	return "", errors.New("file_contains check requires expect.contains or expect.regex")
+}
+
+func (e Engine) jsonPath(c m.AcceptanceCheck) (string, error) {
+	path, err := e.resolve(c.Path)
+	if err != nil {
+		return "", err
+	}
+	expr, ok := c.Expect["path"].(string)
+	if !ok || strings.TrimSpace(expr) == "" {
+		return "", errors.New("json_path check requires expect.path")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return "", fmt.Errorf("%s: %w", c.Path, err)
+	}
+	value, found, err := Lookup(doc, expr)
+	if err != nil {
+		return "", err
+	}
+	wantExists := true
+	if v, ok := c.Expect["exists"].(bool); ok {
+		wantExists = v
+	}
+	if found != wantExists {
+		if wantExists {
+			return "", fmt.Errorf("%s: %s not found", c.Path, expr)
+		}
+		return "", fmt.Errorf("%s: %s present", c.Path, expr)
+	}
+	if want, ok := c.Expect["equals"]; ok && found {
+		if !jsonEqual(value, want) {
+			return "", fmt.Errorf("%s: %s = %v, want %v", c.Path, expr, value, want)
+		}
+	}
+	return fmt.Sprintf("%s: %s found=%t", c.Path, expr, found), nil
+}
+
+func (e Engine) resolve(path string) (string, error) {
+	if strings.TrimSpace(path) == "" {
+		return "", errors.New("check missing path")
+	}
+	if filepath.IsAbs(path) || e.Dir == "" {
+		return path, nil
+	}
+	return filepath.Join(e.Dir, path), nil
+}
+
+// Lookup evaluates a minimal JSONPath subset ("$.a.b[0].c", "$" optional) against a decoded document.
+func Lookup(doc any, expr string) (any, bool, error) {
+	expr = strings.TrimSpace(expr)
+	expr = strings.TrimPrefix(expr, "$")
+	expr = strings.TrimPrefix(expr, ".")
+	cur := doc
+	if expr == "" {
+		return cur, true, nil
+	}
+	for _, seg := range strings.Split(expr, ".") {
+		name := seg
+		var indexes []int
+		if i := strings.Index(seg, "["); i >= 0 {
+			name = seg[:i]
+			rest := seg[i:]
+			for rest != "" {
+				end := strings.Index(rest, "]")
+				if rest[0] != '[' || end < 0 {
+					return nil, false, fmt.Errorf("invalid path segment %q", seg)
+				}
+				n, err := strconv.Atoi(rest[1:end])
+				if err != nil {
+					return nil, false, fmt.Errorf("invalid index in %q", seg)
+				}
+				indexes = append(indexes, n)
+				rest = rest[end+1:]
+			}
+		}
+		if name != "" {
+			obj, ok := cur.(map[string]any)
+			if !ok {
+				return nil, false, nil
+			}
+			if cur, ok = obj[name]; !ok {
+				return nil, false, nil
+			}
+		}
+		for _, idx := range indexes {
+			arr, ok := cur.([]any)
+			if !ok || idx < 0 || idx >= len(arr) {
+				return nil, false, nil
+			}
+			cur = arr[idx]
+		}
+	}
+	return cur, true, nil
+}
+
+// jsonEqual compares values after normalizing both through JSON so 1 and 1.0 match.
+func jsonEqual(a, b any) bool {
+	var na, nb any
+	ra, errA := json.Marshal(a)
+	rb, errB := json.Marshal(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	_ = json.Unmarshal(ra, &na)
+	_ = json.Unmarshal(rb, &nb)
+	return reflect.DeepEqual(na, nb)
+}
+
+func asInt(v any) (int, bool) {
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case float64:
+		if n == float64(int(n)) {
+			return int(n), true
+		}
+	case json.Number:
+		i, err := n.Int64()
+		return int(i), err == nil
+	}
+	return 0, false
+}
+
+func defaultExec(ctx context.Context, dir, command string) ([]byte, []byte, int, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/c", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Dir = dir
+	// Grandchildren may keep the output pipes open after the shell is killed on timeout.
+	cmd.WaitDelay = time.Second
+	var out, errBuf bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &errBuf
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		return out.Bytes(), errBuf.Bytes(), -1, ctx.Err()
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return out.Bytes(), errBuf.Bytes(), exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return out.Bytes(), errBuf.Bytes(), -1, err
+	}
+	return out.Bytes(), errBuf.Bytes(), 0, nil
+}
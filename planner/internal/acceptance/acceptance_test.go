@@ -0,0 +1,92 @@
+package acceptance
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	m "github.com/james/tasks-planner/internal/model"
+)
+
+func TestEngineCommandChecks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses POSIX shell")
+	}
+	e := Engine{}
+	cases := []struct {
+		name  string
+		check m.AcceptanceCheck
+		pass  bool
+	}{
+		{"exit zero", m.AcceptanceCheck{Type: TypeCommand, Cmd: "echo ok"}, true},
+		{"exit nonzero", m.AcceptanceCheck{Type: TypeCommand, Cmd: "exit 3"}, false},
+		{"expected exit code", m.AcceptanceCheck{Type: TypeCommand, Cmd: "exit 3", Expect: map[string]any{"exit_code": float64(3)}}, true},
+		{"stdout contains", m.AcceptanceCheck{Type: TypeCommand, Cmd: "echo hello world", Expect: map[string]any{"stdout_contains": "world"}}, true},
+		{"stdout regex miss", m.AcceptanceCheck{Type: TypeCommand, Cmd: "echo hello", Expect: map[string]any{"stdout_regex": "^bye"}}, false},
+		{"timeout", m.AcceptanceCheck{Type: TypeCommand, Cmd: "sleep 5", Timeout: 1}, false},
+		{"missing cmd", m.AcceptanceCheck{Type: TypeCommand}, false},
+		{"unknown type", m.AcceptanceCheck{Type: "vibes"}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			res := e.Run(context.Background(), tc.check)
+			if res.Passed != tc.pass {
+				t.Fatalf("passed=%t want %t (%s)", res.Passed, tc.pass, res.Detail)
+			}
+		})
+	}
+}
+
+func TestEngineFileChecks(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "out.json"), []byte(`{"meta":{"version":"v8"},"tasks":[{"id":"T001","n":2}]}`), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	e := Engine{Dir: dir}
+	cases := []struct {
+		name  string
+		check m.AcceptanceCheck
+		pass  bool
+	}{
+		{"exists", m.AcceptanceCheck{Type: TypeFileExists, Path: "out.json"}, true},
+		{"missing", m.AcceptanceCheck{Type: TypeFileExists, Path: "nope.json"}, false},
+		{"expect absent", m.AcceptanceCheck{Type: TypeFileExists, Path: "nope.json", Expect: map[string]any{"exists": false}}, true},
+		{"contains", m.AcceptanceCheck{Type: TypeFileContains, Path: "out.json", Expect: map[string]any{"contains": `"v8"`}}, true},
+		{"regex miss", m.AcceptanceCheck{Type: TypeFileContains, Path: "out.json", Expect: map[string]any{"regex": "v9"}}, false},
+		{"contains without expectation", m.AcceptanceCheck{Type: TypeFileContains, Path: "out.json"}, false},
+		{"json equals", m.AcceptanceCheck{Type: TypeJSONPath, Path: "out.json", Expect: map[string]any{"path": "$.meta.version", "equals": "v8"}}, true},
+		{"json index", m.AcceptanceCheck{Type: TypeJSONPath, Path: "out.json", Expect: map[string]any{"path": "tasks[0].n", "equals": 2}}, true},
+		{"json mismatch", m.AcceptanceCheck{Type: TypeJSONPath, Path: "out.json", Expect: map[string]any{"path": "$.meta.version", "equals": "v7"}}, false},
+		{"json missing", m.AcceptanceCheck{Type: TypeJSONPath, Path: "out.json", Expect: map[string]any{"path": "$.tasks[3]"}}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			res := e.Run(context.Background(), tc.check)
+			if res.Passed != tc.pass {
+				t.Fatalf("passed=%t want %t (%s)", res.Passed, tc.pass, res.Detail)
+			}
+		})
+	}
+}
+
+func TestEngineRunAllReportsFailures(t *testing.T) {
+	e := Engine{Exec: func(ctx context.Context, dir, command string) ([]byte, []byte, int, error) {
+		if command == "bad" {
+			return nil, []byte("nope"), 1, nil
+		}
+		return []byte("ok"), nil, 0, nil
+	}}
+	results, err := e.RunAll(context.Background(), []m.AcceptanceCheck{
+		{Type: TypeCommand, Cmd: "good"},
+		{Type: TypeCommand, Cmd: "bad"},
+	})
+	if !errors.Is(err, ErrChecksFailed) {
+		t.Fatalf("expected ErrChecksFailed, got %v", err)
+	}
+	if len(results) != 2 || !results[0].Passed || results[1].Passed || results[1].Index != 1 {
+		t.Fatalf("unexpected results %+v", results)
+	}
+}
@@ -0,0 +1,34 @@
+package exec
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/james/tasks-planner/internal/acceptance"
+	m "github.com/james/tasks-planner/internal/model"
+)
+
+// WithAcceptance wraps run so a task only completes once the worker succeeds and every one of the
+// task's acceptance checks passes; "done" is never the worker's own claim.
+func WithAcceptance(run TaskRunner, engine acceptance.Engine) TaskRunner {
+	return func(ctx context.Context, task m.Task) error {
+		if err := run(ctx, task); err != nil {
+			return err
+		}
+		results, err := engine.RunAll(ctx, task.AcceptanceChecks)
+		if err == nil {
+			return nil
+		}
+		var failed []string
+		for _, res := range results {
+			if !res.Passed {
+				failed = append(failed, fmt.Sprintf("[%d] %s: %s", res.Index, res.Type, res.Detail))
+			}
+		}
+		if len(failed) == 0 {
+			return fmt.Errorf("task %s acceptance: %w", task.ID, err)
+		}
+		return fmt.Errorf("task %s acceptance: %w (%s)", task.ID, err, strings.Join(failed, "; "))
+	}
+}
@@ -0,0 +1,46 @@
+package exec
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/james/tasks-planner/internal/acceptance"
+	m "github.com/james/tasks-planner/internal/model"
+)
+
+func TestWithAcceptanceGatesCompletion(t *testing.T) {
+	engine := acceptance.Engine{Exec: func(ctx context.Context, dir, command string) ([]byte, []byte, int, error) {
+		if command == "fail" {
+			return nil, nil, 1, nil
+		}
+		return nil, nil, 0, nil
+	}}
+	ran := 0
+	run := WithAcceptance(func(ctx context.Context, task m.Task) error { ran++; return nil }, engine)
+
+	ok := m.Task{ID: "A", AcceptanceChecks: []m.AcceptanceCheck{{Type: "command", Cmd: "pass"}}}
+	if err := run(context.Background(), ok); err != nil {
+		t.Fatalf("expected pass, got %v", err)
+	}
+	bad := m.Task{ID: "B", AcceptanceChecks: []m.AcceptanceCheck{{Type: "command", Cmd: "fail"}}}
+	if err := run(context.Background(), bad); !errors.Is(err, acceptance.ErrChecksFailed) {
+		t.Fatalf("expected ErrChecksFailed, got %v", err)
+	}
+	if ran != 2 {
+		t.Fatalf("worker should run before checks, ran=%d", ran)
+	}
+}
+
+func TestWithAcceptanceSkipsChecksWhenWorkerFails(t *testing.T) {
+	engine := acceptance.Engine{Exec: func(ctx context.Context, dir, command string) ([]byte, []byte, int, error) {
+		t.Fatalf("checks must not run after worker failure")
+		return nil, nil, 0, nil
+	}}
+	boom := errors.New("boom")
+	run := WithAcceptance(func(ctx context.Context, task m.Task) error { return boom }, engine)
+	task := m.Task{ID: "A", AcceptanceChecks: []m.AcceptanceCheck{{Type: "command", Cmd: "x"}}}
+	if err := run(context.Background(), task); !errors.Is(err, boom) {
+		t.Fatalf("expected worker error, got %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package exec
+
+import (
+	"time"
+
+	m "github.com/james/tasks-planner/internal/model"
+	"github.com/james/tasks-planner/internal/provenance"
+)
+
+// TaskView is one task of a live GraphSnapshot.
+type TaskView struct {
+	ID       string    `json:"id"`
+	Title    string    `json:"title"`
+	State    TaskState `json:"state"`
+	Progress float64   `json:"progress"`
+	Attempt  int       `json:"attempt,omitempty"`
+	WorkerID string    `json:"worker_id,omitempty"`
+	// NonConformingTelemetry flags a task whose worker emitted invalid JSONL telemetry.
+	NonConformingTelemetry bool `json:"non_conforming_telemetry,omitempty"`
+}
+
+// GraphSnapshot is the executing graph with live task states, as served to operators.
+type GraphSnapshot struct {
+	GraphHash string     `json:"graph_hash"`
+	Tasks     []TaskView `json:"tasks"`
+	Edges     []m.Edge   `json:"edges"`
+}
+
+// Graph snapshots the contract being executed (patches included) with each task's state.
+func (r *Runtime) Graph() (GraphSnapshot, error) {
+	flagged := map[string]bool{}
+	if r.NonConforming != nil {
+		for _, id := range r.NonConforming() {
+			flagged[id] = true
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	h, err := GraphHash(r.coord)
+	if err != nil {
+		return GraphSnapshot{}, err
+	}
+	snap := GraphSnapshot{GraphHash: h, Edges: append([]m.Edge{}, r.coord.Graph.Edges...), Tasks: []TaskView{}}
+	if r.sched == nil {
+		return snap, nil
+	}
+	for _, t := range r.coord.Graph.Nodes {
+		st, _ := r.sched.State(t.ID)
+		v := TaskView{ID: t.ID, Title: t.Title, State: st, Progress: r.sched.Progress(t.ID), NonConformingTelemetry: flagged[t.ID]}
+		if st != TaskPending && st != TaskBlocked {
+			v.Attempt = r.retries[t.ID] + 1
+		}
+		if r.Workers != nil {
+			v.WorkerID = r.Workers.Assigned(t.ID)
+		}
+		snap.Tasks = append(snap.Tasks, v)
+	}
+	return snap, nil
+}
+
+// AdminPrincipal is the identity admin records are attributed to: whoever holds the admin token.
+const AdminPrincipal = "admin-token"
+
+// AdminAction is an operator write made through the admin API. The API authenticates a shared
+// token, not a person, so the operator is only what the client asserted.
+type AdminAction struct {
+	// AssertedOperator is the client-supplied operator name, unverified (empty when not given).
+	AssertedOperator string
+	Verb             string
+	// Time is stamped with the runtime clock when the action goes through Service.AuditAdmin.
+	Time time.Time
+	// Summary describes the payload and outcome; it must be JSON-encodable.
+	Summary map[string]any
+	Err     error
+}
+
+// RecordAdmin appends an admin record naming the authenticated principal (the admin token), the
+// asserted operator, the verb and the payload summary.
+func (p *ProvenanceRecorder) RecordAdmin(a AdminAction) error {
+	fields := map[string]any{"principal": AdminPrincipal, "verb": a.Verb, "summary": a.Summary}
+	if a.AssertedOperator != "" {
+		fields["asserted_operator"] = a.AssertedOperator
+	}
+	data, err := ledgerData(fields)
+	if err != nil {
+		return err
+	}
+	rec := provenance.Record{Kind: provenance.KindAdmin, Time: formatTime(a.Time), Data: data}
+	if a.Err != nil {
+		rec.Error = a.Err.Error()
+	}
+	_, err = p.Ledger.Append(rec)
+	return err
+}
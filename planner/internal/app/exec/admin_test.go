@@ -0,0 +1,96 @@
+package exec
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	m "github.com/james/tasks-planner/internal/model"
+	"github.com/james/tasks-planner/internal/provenance"
+)
+
+func TestRuntimeGraphReportsLiveStates(t *testing.T) {
+	rt := &Runtime{Runner: func(ctx context.Context, task m.Task) error {
+		if task.ID == "B" {
+			return errors.New("boom")
+		}
+		return nil
+	}}
+	if err := rt.Init(context.Background(), chainCoordinator([]string{"A", "B", "C"}, [][2]string{{"A", "B"}, {"B", "C"}})); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	snap, err := rt.Graph()
+	if err != nil || len(snap.Tasks) != 3 || snap.Tasks[0].State != TaskReady || len(snap.Edges) != 2 || snap.GraphHash == "" {
+		t.Fatalf("unexpected snapshot before run %+v (%v)", snap, err)
+	}
+	_ = rt.Run(context.Background())
+	snap, _ = rt.Graph()
+	got := map[string]TaskState{}
+	for _, v := range snap.Tasks {
+		got[v.ID] = v.State
+	}
+	if got["A"] != TaskDone || got["B"] != TaskFailed || got["C"] != TaskBlocked {
+		t.Fatalf("unexpected states %v", got)
+	}
+}
+
+func TestRuntimeGraphFlagsNonConformingTelemetry(t *testing.T) {
+	monitor := &TelemetryMonitor{}
+	rt := &Runtime{
+		Runner: func(ctx context.Context, task m.Task) error {
+			w, wait, err := monitor.Watch(task)
+			if err != nil {
+				return err
+			}
+			if task.ID == "B" {
+				_, _ = w.Write([]byte("not json\n"))
+			}
+			_, err = wait()
+			return err
+		},
+		NonConforming: monitor.NonConforming,
+	}
+	if err := rt.Init(context.Background(), chainCoordinator([]string{"A", "B"}, nil)); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	if err := rt.Run(context.Background()); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	snap, err := rt.Graph()
+	if err != nil {
+		t.Fatalf("graph: %v", err)
+	}
+	got := map[string]bool{}
+	for _, v := range snap.Tasks {
+		got[v.ID] = v.NonConformingTelemetry
+	}
+	if got["A"] || !got["B"] {
+		t.Fatalf("expected only B flagged, got %v", got)
+	}
+}
+
+func TestProvenanceRecorderLedgersAdminActions(t *testing.T) {
+	ledger, err := provenance.Open(filepath.Join(t.TempDir(), "provenance.jsonl"))
+	if err != nil {
+		t.Fatalf("open ledger: %v", err)
+	}
+	defer ledger.Close()
+	rec := &ProvenanceRecorder{Ledger: ledger}
+	err = rec.RecordAdmin(AdminAction{
+		AssertedOperator: "alice", Verb: "patch", Time: time.Unix(0, 0),
+		Summary: map[string]any{"patch_id": "P1", "ops": 2}, Err: ErrPatchRejected,
+	})
+	if err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	recs, err := ledger.Records(provenance.Query{Kind: provenance.KindAdmin})
+	if err != nil || len(recs) != 1 {
+		t.Fatalf("expected one admin record, got %+v (%v)", recs, err)
+	}
+	summary, _ := recs[0].Data["summary"].(map[string]any)
+	if recs[0].Data["asserted_operator"] != "alice" || recs[0].Data["principal"] != AdminPrincipal || summary["ops"] != float64(2) || recs[0].Error == "" {
+		t.Fatalf("unexpected admin record %+v", recs[0])
+	}
+}
@@ -0,0 +1,395 @@
+package exec
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	m "github.com/james/tasks-planner/internal/model"
+	"github.com/james/tasks-planner/internal/telemetry"
+)
+
+// BreakerState is the observable and persisted state of one circuit breaker. Hits holds the
+// matching failures still inside the window; OpenUntil is when an open breaker closes again.
+type BreakerState struct {
+	Name        string      `json:"name"`
+	Action      string      `json:"action"`
+	Open        bool        `json:"open"`
+	OpenedAt    *time.Time  `json:"opened_at,omitempty"`
+	OpenUntil   *time.Time  `json:"open_until,omitempty"`
+	Trips       int         `json:"trips"`
+	Hits        []time.Time `json:"hits,omitempty"`
+	Fingerprint string      `json:"fingerprint,omitempty"`
+	TaskID      string      `json:"task_id,omitempty"`
+}
+
+type breakerFile struct {
+	Breakers []BreakerState `json:"breakers"`
+}
+
+type breaker struct {
+	cfg       m.CircuitBreaker
+	re        *regexp.Regexp
+	window    time.Duration
+	cooldown  time.Duration
+	tasks     map[string]bool
+	resources map[string]bool
+	state     BreakerState
+}
+
+// BreakerSet evaluates Policies.CircuitBreakers against telemetry error fingerprints and acts as a
+// ResourceGate: open "pause" breakers hold back the tasks they scope, and open
+// "reduce_concurrency" breakers cap how many tasks run at once. When Path is set, state is
+// reloaded at construction and rewritten on every observation so a restart resumes it. BreakerSet
+// is safe for concurrent use.
+type BreakerSet struct {
+	Path     string
+	Now      func() time.Time
+	OnChange func(BreakerState)
+
+	mu       sync.Mutex
+	breakers []*breaker
+	running  int
+}
+
+// NewBreakerSet validates cfgs and restores any state persisted at path.
+func NewBreakerSet(cfgs []m.CircuitBreaker, path string) (*BreakerSet, error) {
+	b := &BreakerSet{Path: path}
+	var problems []string
+	seen := map[string]bool{}
+	for _, cfg := range cfgs {
+		br, err := newBreaker(cfg)
+		if err == nil && seen[cfg.Name] {
+			err = errors.New("duplicate name")
+		}
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("breaker %q: %v", cfg.Name, err))
+			continue
+		}
+		seen[cfg.Name] = true
+		b.breakers = append(b.breakers, br)
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid circuit breakers: %s", strings.Join(problems, "; "))
+	}
+	if err := b.load(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func newBreaker(cfg m.CircuitBreaker) (*breaker, error) {
+	if strings.TrimSpace(cfg.Name) == "" {
+		return nil, errors.New("name is required")
+	}
+	if cfg.ErrorCode == "" && cfg.Pattern == "" {
+		return nil, errors.New("error_code or pattern is required")
+	}
+	if cfg.Threshold < 1 {
+		return nil, fmt.Errorf("threshold %d must be at least 1", cfg.Threshold)
+	}
+	if cfg.WindowSeconds < 1 {
+		return nil, fmt.Errorf("window_seconds %d must be at least 1", cfg.WindowSeconds)
+	}
+	switch cfg.Action {
+	case m.BreakerActionPause, m.BreakerActionReduceConcurrency:
+	default:
+		return nil, fmt.Errorf("unknown action %q", cfg.Action)
+	}
+	br := &breaker{
+		cfg:       cfg,
+		window:    time.Duration(cfg.WindowSeconds) * time.Second,
+		cooldown:  time.Duration(cfg.CooldownSeconds) * time.Second,
+		tasks:     toSet(cfg.Tasks),
+		resources: toSet(cfg.Resources),
+		state:     BreakerState{Name: cfg.Name, Action: cfg.Action},
+	}
+	if br.cooldown <= 0 {
+		br.cooldown = br.window
+	}
+	if cfg.Pattern != "" {
+		re, err := regexp.Compile(cfg.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("pattern: %w", err)
+		}
+		br.re = re
+	}
+	return br, nil
+}
+
+func toSet(items []string) map[string]bool {
+	out := make(map[string]bool, len(items))
+	for _, it := range items {
+		out[it] = true
+	}
+	return out
+}
+
+// matches reports whether an error line carries this breaker's fingerprint.
+func (br *breaker) matches(code, message string) bool {
+	if br.cfg.ErrorCode != "" && code != br.cfg.ErrorCode {
+		return false
+	}
+	return br.re == nil || br.re.MatchString(message)
+}
+
+// scopes reports whether task is held back while a pause breaker is open.
+func (br *breaker) scopes(task m.Task) bool {
+	if len(br.tasks) == 0 && len(br.resources) == 0 {
+		return true
+	}
+	if br.tasks[task.ID] {
+		return true
+	}
+	for _, r := range task.Resources.Exclusive {
+		if br.resources[r] {
+			return true
+		}
+	}
+	for _, need := range task.Resources.Limited {
+		if br.resources[need.Name] {
+			return true
+		}
+	}
+	return false
+}
+
+// expire closes the breaker once its cooldown has elapsed and drops hits outside the window.
+// It reports whether the breaker closed.
+func (br *breaker) expire(now time.Time) bool {
+	closed := false
+	if br.state.Open && br.state.OpenUntil != nil && !now.Before(*br.state.OpenUntil) {
+		br.state.Open = false
+		br.state.OpenedAt = nil
+		br.state.OpenUntil = nil
+		closed = true
+	}
+	cutoff := now.Add(-br.window)
+	kept := br.state.Hits[:0]
+	for _, h := range br.state.Hits {
+		if h.After(cutoff) {
+			kept = append(kept, h)
+		}
+	}
+	br.state.Hits = kept
+	return closed
+}
+
+func (b *BreakerSet) now() time.Time {
+	if b.Now != nil {
+		return b.Now()
+	}
+	return time.Now()
+}
+
+// Observe feeds one telemetry line to every breaker. Only error lines carry fingerprints. It
+// returns an error only when the updated state could not be persisted.
+func (b *BreakerSet) Observe(task m.Task, line telemetry.Line) error {
+	if line.Status != telemetry.StatusError {
+		return nil
+	}
+	code := line.ErrorCode()
+	fingerprint := strings.TrimSpace(code + " " + line.Message)
+	b.mu.Lock()
+	now := b.now()
+	var changed []BreakerState
+	matched := false
+	for _, br := range b.breakers {
+		if br.expire(now) {
+			changed = append(changed, br.snapshot())
+		}
+		if !br.matches(code, line.Message) {
+			continue
+		}
+		matched = true
+		br.state.Fingerprint = fingerprint
+		br.state.TaskID = task.ID
+		if br.state.Open {
+			continue
+		}
+		br.state.Hits = append(br.state.Hits, now)
+		if len(br.state.Hits) >= br.cfg.Threshold {
+			until := now.Add(br.cooldown)
+			opened := now
+			br.state.Open = true
+			br.state.OpenedAt = &opened
+			br.state.OpenUntil = &until
+			br.state.Trips++
+			br.state.Hits = nil
+			changed = append(changed, br.snapshot())
+		}
+	}
+	var err error
+	if matched || len(changed) > 0 {
+		err = b.save()
+	}
+	b.mu.Unlock()
+	b.notify(changed)
+	return err
+}
+
+// TryAcquire refuses tasks scoped by an open pause breaker and enforces the lowest concurrency
+// cap among open reduce_concurrency breakers.
+func (b *BreakerSet) TryAcquire(task m.Task) bool {
+	b.mu.Lock()
+	now := b.now()
+	var changed []BreakerState
+	limit := 0
+	admit := true
+	for _, br := range b.breakers {
+		if br.expire(now) {
+			changed = append(changed, br.snapshot())
+		}
+		if !br.state.Open {
+			continue
+		}
+		switch br.cfg.Action {
+		case m.BreakerActionPause:
+			if br.scopes(task) {
+				admit = false
+			}
+		case m.BreakerActionReduceConcurrency:
+			capacity := br.cfg.Concurrency
+			if capacity < 1 {
+				capacity = 1
+			}
+			if limit == 0 || capacity < limit {
+				limit = capacity
+			}
+		}
+	}
+	if limit > 0 && b.running >= limit {
+		admit = false
+	}
+	if admit {
+		b.running++
+	}
+	b.mu.Unlock()
+	b.notify(changed)
+	return admit
+}
+
+// Release returns the concurrency slot taken by TryAcquire.
+func (b *BreakerSet) Release(task m.Task) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.running > 0 {
+		b.running--
+	}
+}
+
+// NextReopen returns the earliest time an open breaker closes, so a runtime with nothing in
+// flight knows when held-back tasks become dispatchable again.
+func (b *BreakerSet) NextReopen() (time.Time, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var next time.Time
+	found := false
+	for _, br := range b.breakers {
+		if !br.state.Open || br.state.OpenUntil == nil {
+			continue
+		}
+		if !found || br.state.OpenUntil.Before(next) {
+			next = *br.state.OpenUntil
+			found = true
+		}
+	}
+	return next, found
+}
+
+// States returns every breaker's current state sorted by name.
+func (b *BreakerSet) States() []BreakerState {
+	b.mu.Lock()
+	now := b.now()
+	var changed []BreakerState
+	out := make([]BreakerState, 0, len(b.breakers))
+	for _, br := range b.breakers {
+		if br.expire(now) {
+			changed = append(changed, br.snapshot())
+		}
+		out = append(out, br.snapshot())
+	}
+	b.mu.Unlock()
+	b.notify(changed)
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+func (br *breaker) snapshot() BreakerState {
+	st := br.state
+	st.Hits = append([]time.Time(nil), br.state.Hits...)
+	return st
+}
+
+func (b *BreakerSet) notify(changed []BreakerState) {
+	if b.OnChange == nil {
+		return
+	}
+	for _, st := range changed {
+		b.OnChange(st)
+	}
+}
+
+// load restores persisted state for breakers still present in the config. Entries for breakers
+// that were removed from the contract are ignored.
+func (b *BreakerSet) load() error {
+	if b.Path == "" {
+		return nil
+	}
+	raw, err := os.ReadFile(b.Path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read breaker state: %w", err)
+	}
+	var file breakerFile
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return fmt.Errorf("decode breaker state %s: %w", b.Path, err)
+	}
+	saved := make(map[string]BreakerState, len(file.Breakers))
+	for _, st := range file.Breakers {
+		saved[st.Name] = st
+	}
+	for _, br := range b.breakers {
+		if st, ok := saved[br.cfg.Name]; ok {
+			st.Action = br.cfg.Action
+			br.state = st
+		}
+	}
+	return nil
+}
+
+// save writes every breaker's state atomically. Callers hold b.mu.
+func (b *BreakerSet) save() error {
+	if b.Path == "" {
+		return nil
+	}
+	file := breakerFile{Breakers: make([]BreakerState, 0, len(b.breakers))}
+	for _, br := range b.breakers {
+		file.Breakers = append(file.Breakers, br.snapshot())
+	}
+	sort.Slice(file.Breakers, func(i, j int) bool { return file.Breakers[i].Name < file.Breakers[j].Name })
+	raw, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode breaker state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(b.Path), 0o755); err != nil {
+		return fmt.Errorf("write breaker state: %w", err)
+	}
+	tmp := b.Path + ".tmp"
+	if err := os.WriteFile(tmp, append(raw, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write breaker state: %w", err)
+	}
+	if err := os.Rename(tmp, b.Path); err != nil {
+		return fmt.Errorf("write breaker state: %w", err)
+	}
+	return nil
+}
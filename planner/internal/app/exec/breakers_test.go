@@ -0,0 +1,128 @@
+package exec
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	m "github.com/james/tasks-planner/internal/model"
+	"github.com/james/tasks-planner/internal/telemetry"
+)
+
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) Now() time.Time          { return c.t }
+func (c *fakeClock) Advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func errorLine(code, msg string) telemetry.Line {
+	return telemetry.Line{Status: telemetry.StatusError, Message: msg, Data: map[string]any{"error_code": code}}
+}
+
+func TestBreakerTripsAfterThresholdWithinWindow(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1000, 0)}
+	var changes []BreakerState
+	b, err := NewBreakerSet([]m.CircuitBreaker{{
+		Name: "deps", ErrorCode: "E_DEPS", Pattern: "module not found",
+		WindowSeconds: 60, Threshold: 2, Action: m.BreakerActionPause, Resources: []string{"npm"},
+	}}, "")
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	b.Now = clock.Now
+	b.OnChange = func(st BreakerState) { changes = append(changes, st) }
+
+	npm := m.Task{ID: "UI"}
+	npm.Resources.Exclusive = []string{"npm"}
+	other := m.Task{ID: "API"}
+
+	_ = b.Observe(npm, errorLine("E_DEPS", "module not found: react"))
+	clock.Advance(90 * time.Second) // first hit leaves the window
+	_ = b.Observe(npm, errorLine("E_DEPS", "module not found: vue"))
+	_ = b.Observe(npm, errorLine("E_OTHER", "module not found: vue"))
+	if b.States()[0].Open {
+		t.Fatal("breaker should not trip on hits outside the window or other codes")
+	}
+	_ = b.Observe(npm, errorLine("E_DEPS", "module not found: vue"))
+	st := b.States()[0]
+	if !st.Open || st.Trips != 1 || st.TaskID != "UI" {
+		t.Fatalf("expected open breaker, got %+v", st)
+	}
+	if b.TryAcquire(npm) {
+		t.Fatal("task using npm should be paused")
+	}
+	if !b.TryAcquire(other) {
+		t.Fatal("unscoped task should still run")
+	}
+	clock.Advance(61 * time.Second)
+	if !b.TryAcquire(npm) {
+		t.Fatal("breaker should close after the cooldown")
+	}
+	if len(changes) != 2 || !changes[0].Open || changes[1].Open {
+		t.Fatalf("expected open then close notifications, got %+v", changes)
+	}
+}
+
+func TestBreakerReducesConcurrency(t *testing.T) {
+	b, err := NewBreakerSet([]m.CircuitBreaker{{
+		Name: "oom", Pattern: "(?i)out of memory", WindowSeconds: 60, Threshold: 1,
+		Action: m.BreakerActionReduceConcurrency, Concurrency: 1,
+	}}, "")
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	a, c := m.Task{ID: "A"}, m.Task{ID: "C"}
+	if !b.TryAcquire(a) || !b.TryAcquire(c) {
+		t.Fatal("closed breaker should not limit")
+	}
+	b.Release(a)
+	b.Release(c)
+	_ = b.Observe(a, errorLine("E1", "Out Of Memory"))
+	if !b.TryAcquire(a) {
+		t.Fatal("first task should fit under the reduced limit")
+	}
+	if b.TryAcquire(c) {
+		t.Fatal("second task should exceed the reduced limit")
+	}
+}
+
+func TestBreakerStatePersistsAcrossRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "breakers.json")
+	cfg := []m.CircuitBreaker{{Name: "net", ErrorCode: "E_NET", WindowSeconds: 300, Threshold: 2, Action: m.BreakerActionPause}}
+	b, err := NewBreakerSet(cfg, path)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	_ = b.Observe(m.Task{ID: "A"}, errorLine("E_NET", "timeout"))
+	if err := b.Observe(m.Task{ID: "A"}, errorLine("E_NET", "timeout")); err != nil {
+		t.Fatalf("observe: %v", err)
+	}
+
+	restarted, err := NewBreakerSet(cfg, path)
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	st := restarted.States()[0]
+	if !st.Open || st.Trips != 1 {
+		t.Fatalf("expected persisted open breaker, got %+v", st)
+	}
+	if restarted.TryAcquire(m.Task{ID: "B"}) {
+		t.Fatal("restored breaker should keep pausing tasks")
+	}
+}
+
+func TestBreakerConfigValidation(t *testing.T) {
+	_, err := NewBreakerSet([]m.CircuitBreaker{
+		{Name: "a", WindowSeconds: 1, Threshold: 1, Action: m.BreakerActionPause},
+		{Name: "b", ErrorCode: "X", WindowSeconds: 1, Threshold: 1, Action: "explode"},
+		{Name: "c", Pattern: "(", WindowSeconds: 1, Threshold: 1, Action: m.BreakerActionPause},
+	}, "")
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	for _, want := range []string{`"a"`, `"b"`, `"c"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("error %q does not mention %s", err, want)
+		}
+	}
+}
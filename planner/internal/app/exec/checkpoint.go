@@ -0,0 +1,272 @@
+package exec
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/james/tasks-planner/internal/canonjson"
+	"github.com/james/tasks-planner/internal/hash"
+	m "github.com/james/tasks-planner/internal/model"
+	"github.com/james/tasks-planner/internal/provenance"
+)
+
+var (
+	// ErrNoCheckpoint is returned when resuming without any recorded executor state.
+	ErrNoCheckpoint = errors.New("no checkpoint to resume")
+	// ErrCoordinatorChanged is returned when the recorded state belongs to a different contract.
+	ErrCoordinatorChanged = errors.New("coordinator changed since checkpoint")
+	// ErrProfileChanged is returned when the recorded state ran under a different resource profile.
+	ErrProfileChanged = errors.New("resource profile changed since checkpoint")
+)
+
+// Checkpoint is the executor state persisted after every transition so an interrupted run can
+// resume. Run identifies the run (kept across resumes) and Seq orders checkpoints within it;
+// ledger records carry the ID of the checkpoint that reflects them.
+type Checkpoint struct {
+	CoordinatorHash string `json:"coordinator_hash"`
+	// Profile is the resource profile overlaid when the run started; the hash predates it.
+	Profile  string               `json:"profile,omitempty"`
+	Run      string               `json:"run"`
+	Seq      int                  `json:"seq"`
+	Time     string               `json:"time"`
+	States   map[string]TaskState `json:"states"`
+	Locks    map[string]string    `json:"locks,omitempty"`
+	Requeued map[string]int       `json:"requeued,omitempty"`
+	Retries  map[string]int       `json:"retries,omitempty"`
+	Patches  []m.Patch            `json:"patches,omitempty"`
+}
+
+// ID returns the checkpoint identifier recorded in the provenance ledger.
+func (c Checkpoint) ID() string {
+	return checkpointID(c.Run, c.Seq)
+}
+
+func checkpointID(run string, seq int) string {
+	return fmt.Sprintf("%s.%d", run, seq)
+}
+
+// parseCheckpointID splits an identifier written by checkpointID.
+func parseCheckpointID(id string) (string, int, bool) {
+	i := strings.LastIndex(id, ".")
+	if i < 0 {
+		return "", 0, false
+	}
+	seq, err := strconv.Atoi(id[i+1:])
+	if err != nil {
+		return "", 0, false
+	}
+	return id[:i], seq, true
+}
+
+// CoordinatorHash returns the SHA-256 of the coordinator's canonical JSON.
+func CoordinatorHash(coord m.Coordinator) (string, error) {
+	raw, err := json.Marshal(coord)
+	if err != nil {
+		return "", fmt.Errorf("encode coordinator: %w", err)
+	}
+	can, err := canonjson.ToCanonicalJSON(raw)
+	if err != nil {
+		return "", fmt.Errorf("canonicalize coordinator: %w", err)
+	}
+	return hash.HashCanonicalBytes(can), nil
+}
+
+// CheckpointStore keeps executor state for one contract under Dir/<coordinator hash>/.
+type CheckpointStore struct {
+	Dir  string
+	Hash string
+}
+
+// Path returns the checkpoint file.
+func (s CheckpointStore) Path() string {
+	return filepath.Join(s.Dir, s.Hash, "checkpoint.json")
+}
+
+// BreakerPath returns where circuit breaker state for this contract lives.
+func (s CheckpointStore) BreakerPath() string {
+	return filepath.Join(s.Dir, s.Hash, "breakers.json")
+}
+
+// Save writes cp atomically.
+func (s CheckpointStore) Save(cp Checkpoint) error {
+	raw, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode checkpoint: %w", err)
+	}
+	path := s.Path()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("write checkpoint: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(raw, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("write checkpoint: %w", err)
+	}
+	return nil
+}
+
+// Load reads the checkpoint for s.Hash. When there is none but state exists for other contracts,
+// the coordinator was edited since the interrupted run and ErrCoordinatorChanged is returned.
+func (s CheckpointStore) Load() (Checkpoint, error) {
+	raw, err := os.ReadFile(s.Path())
+	if os.IsNotExist(err) {
+		if others := s.recorded(); len(others) > 0 {
+			return Checkpoint{}, fmt.Errorf("%w: state recorded for %s, coordinator is now %s",
+				ErrCoordinatorChanged, strings.Join(others, ", "), s.Hash)
+		}
+		return Checkpoint{}, fmt.Errorf("%w in %s", ErrNoCheckpoint, s.Dir)
+	}
+	if err != nil {
+		return Checkpoint{}, fmt.Errorf("read checkpoint: %w", err)
+	}
+	var cp Checkpoint
+	if err := json.Unmarshal(raw, &cp); err != nil {
+		return Checkpoint{}, fmt.Errorf("decode checkpoint %s: %w", s.Path(), err)
+	}
+	if cp.CoordinatorHash != s.Hash {
+		return Checkpoint{}, fmt.Errorf("%w: checkpoint records %s, coordinator is now %s",
+			ErrCoordinatorChanged, cp.CoordinatorHash, s.Hash)
+	}
+	return cp, nil
+}
+
+// recorded lists the coordinator hashes that have a checkpoint in Dir.
+func (s CheckpointStore) recorded() []string {
+	entries, err := os.ReadDir(s.Dir)
+	if err != nil {
+		return nil
+	}
+	var out []string
+	for _, e := range entries {
+		if !e.IsDir() || e.Name() == s.Hash {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(s.Dir, e.Name(), "checkpoint.json")); err == nil {
+			out = append(out, e.Name())
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// ResumeSummary describes the state a resumed run continues from.
+type ResumeSummary struct {
+	Checkpoint  string
+	Done        []string
+	Interrupted []string
+	Patches     []string
+}
+
+// Resume continues the run recorded in cp on a freshly initialised runtime, refusing a checkpoint
+// taken for another coordinator or resource profile. Ledger records written after cp (the process
+// stopped between recording a transition and checkpointing it) are replayed on top: their
+// completions count as done, their patches are re-applied and their requeues and retries count
+// against MaxCompensations and the retry policy. Tasks that were running, failed or blocked start
+// over as pending.
+func (r *Runtime) Resume(cp Checkpoint, records []provenance.Record) (ResumeSummary, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.store == nil {
+		return ResumeSummary{}, fmt.Errorf("resume: no state dir configured")
+	}
+	if cp.CoordinatorHash != r.store.Hash {
+		return ResumeSummary{}, fmt.Errorf("%w: checkpoint records %s, coordinator is now %s",
+			ErrCoordinatorChanged, cp.CoordinatorHash, r.store.Hash)
+	}
+	if cp.Profile != r.Profile {
+		return ResumeSummary{}, fmt.Errorf("%w: checkpoint ran under %q, resuming with %q",
+			ErrProfileChanged, cp.Profile, r.Profile)
+	}
+	sum := ResumeSummary{Checkpoint: cp.ID()}
+	applied := map[string]bool{}
+	patch := func(p m.Patch) error {
+		if applied[p.ID] {
+			return nil
+		}
+		coord, res, err := ApplyPatch(r.coord, p)
+		if err != nil {
+			return fmt.Errorf("resume: %w", err)
+		}
+		if err := r.extendLocked(coord, patchTasks(p), res.Edges, p); err != nil {
+			return fmt.Errorf("resume: patch %s: %w", p.ID, err)
+		}
+		applied[p.ID] = true
+		sum.Patches = append(sum.Patches, p.ID)
+		return nil
+	}
+	for _, p := range cp.Patches {
+		if err := patch(p); err != nil {
+			return sum, err
+		}
+	}
+
+	done := map[string]bool{}
+	for id, st := range cp.States {
+		switch st {
+		case TaskDone:
+			done[id] = true
+		case TaskRunning:
+			sum.Interrupted = append(sum.Interrupted, id)
+		}
+	}
+	requeued := make(map[string]int, len(cp.Requeued))
+	for id, n := range cp.Requeued {
+		requeued[id] = n
+	}
+	retries := make(map[string]int, len(cp.Retries))
+	for id, n := range cp.Retries {
+		retries[id] = n
+	}
+	seq := cp.Seq
+	for _, rec := range records {
+		run, n, ok := parseCheckpointID(rec.CheckpointID)
+		if !ok || run != cp.Run || n <= cp.Seq {
+			continue
+		}
+		if n > seq {
+			seq = n
+		}
+		switch rec.Kind {
+		case provenance.KindComplete:
+			done[rec.TaskID] = true
+		case provenance.KindRequeue:
+			requeued[rec.TaskID]++
+		case provenance.KindRetry:
+			retries[rec.TaskID]++
+		case provenance.KindPatch:
+			var p m.Patch
+			raw, err := json.Marshal(rec.Data["patch"])
+			if err == nil {
+				err = json.Unmarshal(raw, &p)
+			}
+			if err != nil {
+				return sum, fmt.Errorf("resume: decode patch in ledger record %d: %w", rec.Seq, err)
+			}
+			if err := patch(p); err != nil {
+				return sum, err
+			}
+		}
+	}
+
+	for id := range done {
+		sum.Done = append(sum.Done, id)
+	}
+	sort.Strings(sum.Done)
+	sort.Strings(sum.Interrupted)
+	if err := r.sched.Restore(sum.Done); err != nil {
+		return sum, fmt.Errorf("resume: %w", err)
+	}
+	r.requeued = requeued
+	r.retries = retries
+	r.run = cp.Run
+	r.cpSeq = seq
+	return sum, r.saveCheckpointLocked()
+}
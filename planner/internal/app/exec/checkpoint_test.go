@@ -0,0 +1,129 @@
+package exec
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	m "github.com/james/tasks-planner/internal/model"
+	"github.com/james/tasks-planner/internal/provenance"
+)
+
+func TestCheckpointStoreDetectsCoordinatorChange(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := (CheckpointStore{Dir: dir, Hash: "aaa"}).Load(); !errors.Is(err, ErrNoCheckpoint) {
+		t.Fatalf("expected ErrNoCheckpoint, got %v", err)
+	}
+	cp := Checkpoint{CoordinatorHash: "aaa", Run: "r1", Seq: 3, States: map[string]TaskState{"A": TaskDone}}
+	if err := (CheckpointStore{Dir: dir, Hash: "aaa"}).Save(cp); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	got, err := CheckpointStore{Dir: dir, Hash: "aaa"}.Load()
+	if err != nil || got.ID() != "r1.3" || got.States["A"] != TaskDone {
+		t.Fatalf("unexpected load %+v, %v", got, err)
+	}
+	if _, err := (CheckpointStore{Dir: dir, Hash: "bbb"}).Load(); !errors.Is(err, ErrCoordinatorChanged) {
+		t.Fatalf("expected ErrCoordinatorChanged, got %v", err)
+	}
+}
+
+func TestRuntimeResumesFromCheckpointAndLedger(t *testing.T) {
+	dir := t.TempDir()
+	coord := chainCoordinator([]string{"A", "B", "C", "D"}, [][2]string{{"A", "B"}, {"B", "C"}, {"C", "D"}})
+	var rec []Event
+	first := &Runtime{
+		StateDir: dir,
+		Runner: func(ctx context.Context, task m.Task) error {
+			if task.ID == "C" {
+				return errors.New("interrupted")
+			}
+			return nil
+		},
+		Notify: func(ev Event) { rec = append(rec, ev) },
+	}
+	if err := first.Init(context.Background(), coord); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	if err := first.Run(context.Background()); !errors.Is(err, ErrTasksFailed) {
+		t.Fatalf("expected ErrTasksFailed, got %v", err)
+	}
+	h, err := CoordinatorHash(coord)
+	if err != nil {
+		t.Fatalf("hash: %v", err)
+	}
+	cp, err := CheckpointStore{Dir: dir, Hash: h}.Load()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cp.States["B"] != TaskDone || cp.States["C"] != TaskFailed || rec[len(rec)-1].CheckpointID != cp.ID() {
+		t.Fatalf("checkpoint does not reflect the last transition: %+v", cp)
+	}
+
+	// C completed after the last checkpoint; only the ledger knows.
+	ledger := []provenance.Record{
+		{Kind: provenance.KindComplete, TaskID: "A", CheckpointID: checkpointID(cp.Run, 1)},
+		{Kind: provenance.KindComplete, TaskID: "C", CheckpointID: checkpointID(cp.Run, cp.Seq+1)},
+	}
+	var ran []string
+	second := &Runtime{StateDir: dir, Runner: func(ctx context.Context, task m.Task) error {
+		ran = append(ran, task.ID)
+		return nil
+	}}
+	if err := second.Init(context.Background(), coord); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	sum, err := second.Resume(cp, ledger)
+	if err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	if len(sum.Done) != 3 {
+		t.Fatalf("expected A, B, C done, got %v", sum.Done)
+	}
+	if err := second.Run(context.Background()); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if len(ran) != 1 || ran[0] != "D" {
+		t.Fatalf("expected only D to run, got %v", ran)
+	}
+	after, err := CheckpointStore{Dir: dir, Hash: h}.Load()
+	if err != nil || after.Run != cp.Run || after.Seq <= cp.Seq+1 {
+		t.Fatalf("resumed run should continue the checkpoint sequence, got %+v, %v", after, err)
+	}
+
+	edited := chainCoordinator([]string{"A", "B"}, [][2]string{{"A", "B"}})
+	third := &Runtime{StateDir: dir, Runner: second.Runner}
+	if err := third.Init(context.Background(), edited); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	if _, err := third.Resume(cp, nil); !errors.Is(err, ErrCoordinatorChanged) {
+		t.Fatalf("expected ErrCoordinatorChanged, got %v", err)
+	}
+}
+
+func TestRuntimeResumeRefusesChangedProfile(t *testing.T) {
+	dir := t.TempDir()
+	coord := profileCoordinator()
+	coord.Graph.Nodes = []m.Task{{ID: "A"}}
+	first := &Runtime{StateDir: dir, Profile: "default", Runner: func(ctx context.Context, task m.Task) error { return nil }}
+	if err := first.Init(context.Background(), coord); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	if err := first.Run(context.Background()); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	h, err := CoordinatorHash(coord)
+	if err != nil {
+		t.Fatalf("hash: %v", err)
+	}
+	cp, err := CheckpointStore{Dir: dir, Hash: h}.Load()
+	if err != nil || cp.Profile != "default" {
+		t.Fatalf("expected the profile checkpointed, got %+v, %v", cp, err)
+	}
+	second := &Runtime{StateDir: dir, Profile: "ci", Runner: first.Runner}
+	if err := second.Init(context.Background(), coord); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	if _, err := second.Resume(cp, nil); !errors.Is(err, ErrProfileChanged) {
+		t.Fatalf("expected ErrProfileChanged, got %v", err)
+	}
+}
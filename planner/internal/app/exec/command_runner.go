@@ -0,0 +1,102 @@
+package exec
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+
+	m "github.com/james/tasks-planner/internal/model"
+)
+
+// CommandRunner executes tasks by invoking a shell command with the task JSON on stdin.
+// The task ID is exported as TASKS_TASK_ID so simple workers need not parse stdin. When a
+// Telemetry monitor is set, stdout of JSONL-logging tasks is also fed through it. Env entries are
+// appended to the process environment.
+type CommandRunner struct {
+	Command   string
+	Env       []string
+	Stdout    io.Writer
+	Stderr    io.Writer
+	Telemetry *TelemetryMonitor
+}
+
+// Run invokes the worker command for task and returns an error on non-zero exit.
+func (r CommandRunner) Run(ctx context.Context, task m.Task) error {
+	payload, err := json.Marshal(task)
+	if err != nil {
+		return fmt.Errorf("encode task %s: %w", task.ID, err)
+	}
+	cmd := shellCommand(ctx, r.Command)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Env = append(append(os.Environ(), r.Env...), "TASKS_TASK_ID="+task.ID)
+	cmd.Stdout = r.Stdout
+	cmd.Stderr = r.Stderr
+	wait := func() error { return nil }
+	if r.Telemetry != nil && r.Telemetry.Enabled(task) {
+		w, waitTelemetry, err := r.Telemetry.Watch(task)
+		if err != nil {
+			return fmt.Errorf("task %s: %w", task.ID, err)
+		}
+		if r.Stdout != nil {
+			w = io.MultiWriter(w, r.Stdout)
+		}
+		cmd.Stdout = w
+		wait = func() error {
+			_, err := waitTelemetry()
+			return err
+		}
+	}
+	runErr := cmd.Run()
+	telemetryErr := wait()
+	if runErr != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("task %s: %w", task.ID, runErr)
+	}
+	if telemetryErr != nil {
+		return fmt.Errorf("task %s telemetry: %w", task.ID, telemetryErr)
+	}
+	return nil
+}
+
+// RollbackRunner runs a task's Compensation.RollbackCmd in Dir with the same stdin and environment
+// contract as CommandRunner.
+type RollbackRunner struct {
+	Dir    string
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// Run invokes the rollback command for task and returns an error on non-zero exit.
+func (r RollbackRunner) Run(ctx context.Context, task m.Task) error {
+	payload, err := json.Marshal(task)
+	if err != nil {
+		return fmt.Errorf("encode task %s: %w", task.ID, err)
+	}
+	cmd := shellCommand(ctx, task.Compensation.RollbackCmd)
+	cmd.Dir = r.Dir
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Env = append(os.Environ(), "TASKS_TASK_ID="+task.ID)
+	cmd.Stdout = r.Stdout
+	cmd.Stderr = r.Stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	return nil
+}
+
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/c", command)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
@@ -0,0 +1,117 @@
+package exec
+
+import (
+	"sort"
+
+	m "github.com/james/tasks-planner/internal/model"
+)
+
+// LockManager governs Task.Resources.Exclusive. Every lock a task needs is taken in one
+// all-or-nothing step following a canonical global order, so no task ever holds a partial set.
+type LockManager struct {
+	rank map[string]lockRank
+	held map[string]string // resource -> holding task ID
+}
+
+// lockRank orders resources: Policies.LockOrdering position first, then ResourceSpec.LockOrder,
+// then lexicographic by name.
+type lockRank struct {
+	tier  int
+	order int
+	name  string
+}
+
+func (a lockRank) less(b lockRank) bool {
+	if a.tier != b.tier {
+		return a.tier < b.tier
+	}
+	if a.order != b.order {
+		return a.order < b.order
+	}
+	return a.name < b.name
+}
+
+// NewLockManager derives the global lock order from the coordinator config.
+func NewLockManager(coord m.Coordinator) *LockManager {
+	l := &LockManager{rank: map[string]lockRank{}, held: map[string]string{}}
+	for name, spec := range coord.Config.Resources.Catalog {
+		if spec.LockOrder != 0 {
+			l.rank[name] = lockRank{tier: 1, order: spec.LockOrder, name: name}
+		}
+	}
+	for i, name := range coord.Config.Policies.LockOrdering {
+		if _, dup := l.rank[name]; dup && l.rank[name].tier == 0 {
+			continue
+		}
+		l.rank[name] = lockRank{tier: 0, order: i, name: name}
+	}
+	return l
+}
+
+// Reorder recomputes the global order after a hot patch changed the catalog. Held locks are kept;
+// since acquisition is all-or-nothing no task waits while holding, so the switch cannot deadlock.
+func (l *LockManager) Reorder(coord m.Coordinator) {
+	l.rank = NewLockManager(coord).rank
+}
+
+func (l *LockManager) rankOf(name string) lockRank {
+	if r, ok := l.rank[name]; ok {
+		return r
+	}
+	return lockRank{tier: 2, name: name}
+}
+
+// Order returns the distinct, non-empty resources in canonical acquisition order.
+func (l *LockManager) Order(resources []string) []string {
+	seen := map[string]bool{}
+	out := make([]string, 0, len(resources))
+	for _, r := range resources {
+		if r == "" || seen[r] {
+			continue
+		}
+		seen[r] = true
+		out = append(out, r)
+	}
+	sort.Slice(out, func(i, j int) bool { return l.rankOf(out[i]).less(l.rankOf(out[j])) })
+	return out
+}
+
+// TryAcquire takes every exclusive lock task needs, or none of them.
+func (l *LockManager) TryAcquire(task m.Task) bool {
+	order := l.Order(task.Resources.Exclusive)
+	for i, r := range order {
+		if holder, busy := l.held[r]; busy && holder != task.ID {
+			for j := i - 1; j >= 0; j-- {
+				delete(l.held, order[j])
+			}
+			return false
+		}
+		l.held[r] = task.ID
+	}
+	return true
+}
+
+// Release frees every lock held by task, in reverse acquisition order.
+func (l *LockManager) Release(task m.Task) {
+	order := l.Order(task.Resources.Exclusive)
+	for i := len(order) - 1; i >= 0; i-- {
+		if l.held[order[i]] == task.ID {
+			delete(l.held, order[i])
+		}
+	}
+}
+
+// Held returns a copy of every held lock keyed by resource.
+func (l *LockManager) Held() map[string]string {
+	out := make(map[string]string, len(l.held))
+	for r, id := range l.held {
+		out[r] = id
+	}
+	return out
+}
+
+// Holder reports which task currently holds resource, if any.
+func (l *LockManager) Holder(resource string) (string, bool) {
+	id, ok := l.held[resource]
+	return id, ok
+}
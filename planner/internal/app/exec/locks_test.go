@@ -0,0 +1,76 @@
+package exec
+
+import (
+	"reflect"
+	"testing"
+
+	m "github.com/james/tasks-planner/internal/model"
+)
+
+func exclusiveTask(id string, resources ...string) m.Task {
+	t := m.Task{ID: id}
+	t.Resources.Exclusive = resources
+	return t
+}
+
+func TestLockManagerGlobalOrder(t *testing.T) {
+	var coord m.Coordinator
+	coord.Config.Policies.LockOrdering = []string{"schema", "repo"}
+	coord.Config.Resources.Catalog = map[string]m.ResourceSpec{
+		"cache":  {LockOrder: 2},
+		"assets": {LockOrder: 1},
+		"schema": {LockOrder: 9},
+	}
+	l := NewLockManager(coord)
+	got := l.Order([]string{"zeta", "cache", "repo", "alpha", "assets", "schema", "repo", ""})
+	want := []string{"schema", "repo", "assets", "cache", "alpha", "zeta"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("order mismatch:\n got %v\nwant %v", got, want)
+	}
+}
+
+func TestLockManagerAllOrNothing(t *testing.T) {
+	l := NewLockManager(m.Coordinator{})
+	a := exclusiveTask("A", "db")
+	b := exclusiveTask("B", "assets", "db")
+	c := exclusiveTask("C", "assets")
+	if !l.TryAcquire(a) {
+		t.Fatalf("A should acquire db")
+	}
+	if l.TryAcquire(b) {
+		t.Fatalf("B must not acquire while db is held")
+	}
+	if _, held := l.Holder("assets"); held {
+		t.Fatalf("B left a partial hold on assets")
+	}
+	if !l.TryAcquire(c) {
+		t.Fatalf("C should acquire assets")
+	}
+	l.Release(a)
+	l.Release(c)
+	if !l.TryAcquire(b) {
+		t.Fatalf("B should acquire once both locks are free")
+	}
+}
+
+func TestSchedulerKeepsLockedTasksInFrontier(t *testing.T) {
+	var coord m.Coordinator
+	coord.Graph.Nodes = []m.Task{exclusiveTask("A", "migrations"), exclusiveTask("B", "migrations"), {ID: "C"}}
+	s, err := NewScheduler(coord)
+	if err != nil {
+		t.Fatalf("new scheduler: %v", err)
+	}
+	s.AddGate(NewLockManager(coord))
+	if got := taskIDs(s.Next()); !reflect.DeepEqual(got, []string{"A", "C"}) {
+		t.Fatalf("expected A,C dispatched, got %v", got)
+	}
+	if st, _ := s.State("B"); st != TaskReady {
+		t.Fatalf("B should remain in the frontier, got %s", st)
+	}
+	if err := s.Complete("A"); err != nil {
+		t.Fatalf("complete A: %v", err)
+	}
+	if got := taskIDs(s.Next()); !reflect.DeepEqual(got, []string{"B"}) {
+		t.Fatalf("expected B after lock release, got %v", got)
+	}
+}
@@ -0,0 +1,120 @@
+package exec
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/james/tasks-planner/internal/canonjson"
+	"github.com/james/tasks-planner/internal/hash"
+	m "github.com/james/tasks-planner/internal/model"
+	"github.com/james/tasks-planner/internal/planner/dag"
+)
+
+// ErrPatchRejected wraps every reason a hot patch is refused.
+var ErrPatchRejected = errors.New("patch rejected")
+
+// PatchResult records an accepted patch and the graph it produced.
+type PatchResult struct {
+	PatchID   string   `json:"patch_id"`
+	GraphHash string   `json:"graph_hash"`
+	Tasks     []string `json:"tasks,omitempty"`
+	Edges     []m.Edge `json:"edges,omitempty"`
+	Resources []string `json:"resources,omitempty"`
+}
+
+// ApplyPatch returns a copy of coord with p applied in op order, plus a summary of what was added.
+// The patched graph must still pass cycle detection in planner/dag and every limited resource need
+// must still fit the catalog; otherwise the patch is rejected and coord is left untouched.
+func ApplyPatch(coord m.Coordinator, p m.Patch) (m.Coordinator, PatchResult, error) {
+	res := PatchResult{PatchID: p.ID}
+	if strings.TrimSpace(p.ID) == "" {
+		return coord, res, fmt.Errorf("%w: id is required", ErrPatchRejected)
+	}
+	if len(p.Ops) == 0 {
+		return coord, res, fmt.Errorf("%w: %s has no ops", ErrPatchRejected, p.ID)
+	}
+	out := coord
+	out.Graph.Nodes = append([]m.Task(nil), coord.Graph.Nodes...)
+	out.Graph.Edges = append([]m.Edge(nil), coord.Graph.Edges...)
+	out.Config.Resources.Catalog = make(map[string]m.ResourceSpec, len(coord.Config.Resources.Catalog))
+	for name, spec := range coord.Config.Resources.Catalog {
+		out.Config.Resources.Catalog[name] = spec
+	}
+	ids := make(map[string]bool, len(out.Graph.Nodes))
+	for _, t := range out.Graph.Nodes {
+		ids[t.ID] = true
+	}
+
+	for i, op := range p.Ops {
+		reject := func(format string, args ...any) error {
+			return fmt.Errorf("%w: %s op %d (%s): %s", ErrPatchRejected, p.ID, i, op.Op, fmt.Sprintf(format, args...))
+		}
+		switch op.Op {
+		case m.PatchAddTask:
+			if op.Task == nil || strings.TrimSpace(op.Task.ID) == "" {
+				return coord, res, reject("task with an id is required")
+			}
+			if ids[op.Task.ID] {
+				return coord, res, reject("task %s already exists", op.Task.ID)
+			}
+			ids[op.Task.ID] = true
+			out.Graph.Nodes = append(out.Graph.Nodes, *op.Task)
+			res.Tasks = append(res.Tasks, op.Task.ID)
+		case m.PatchAddEdge:
+			e := op.Edge
+			if e == nil {
+				return coord, res, reject("edge is required")
+			}
+			if !ids[e.From] || !ids[e.To] {
+				return coord, res, reject("edge %s->%s references an unknown task", e.From, e.To)
+			}
+			if e.From == e.To {
+				return coord, res, reject("edge %s->%s is a self-loop", e.From, e.To)
+			}
+			if strings.TrimSpace(e.Type) == "" {
+				return coord, res, reject("edge %s->%s has no type", e.From, e.To)
+			}
+			out.Graph.Edges = append(out.Graph.Edges, *e)
+			res.Edges = append(res.Edges, *e)
+		case m.PatchModifyResource:
+			if strings.TrimSpace(op.Resource) == "" || op.Spec == nil {
+				return coord, res, reject("resource and spec are required")
+			}
+			if op.Spec.Capacity < 1 {
+				return coord, res, reject("capacity %d for %s must be positive", op.Spec.Capacity, op.Resource)
+			}
+			out.Config.Resources.Catalog[op.Resource] = *op.Spec
+			res.Resources = append(res.Resources, op.Resource)
+		default:
+			return coord, res, reject("unsupported op")
+		}
+	}
+
+	if _, err := dag.Build(out.Graph.Nodes, out.Graph.Edges, 0); err != nil {
+		return coord, res, fmt.Errorf("%w: %s: %v", ErrPatchRejected, p.ID, err)
+	}
+	if _, err := NewQuotaManager(out); err != nil {
+		return coord, res, fmt.Errorf("%w: %s: %v", ErrPatchRejected, p.ID, err)
+	}
+	h, err := GraphHash(out)
+	if err != nil {
+		return coord, res, err
+	}
+	res.GraphHash = h
+	return out, res, nil
+}
+
+// GraphHash returns the SHA-256 of the canonical JSON of the coordinator graph (nodes and edges).
+func GraphHash(coord m.Coordinator) (string, error) {
+	raw, err := json.Marshal(coord.Graph)
+	if err != nil {
+		return "", fmt.Errorf("encode graph: %w", err)
+	}
+	can, err := canonjson.ToCanonicalJSON(raw)
+	if err != nil {
+		return "", fmt.Errorf("canonicalize graph: %w", err)
+	}
+	return hash.HashCanonicalBytes(can), nil
+}
@@ -0,0 +1,67 @@
+package exec
+
+import (
+	"errors"
+	"testing"
+
+	m "github.com/james/tasks-planner/internal/model"
+)
+
+func TestApplyPatchAddsTasksAndEdges(t *testing.T) {
+	coord := chainCoordinator([]string{"A", "B"}, [][2]string{{"A", "B"}})
+	before, err := GraphHash(coord)
+	if err != nil {
+		t.Fatalf("hash: %v", err)
+	}
+	p := m.Patch{ID: "deps-1", Ops: []m.PatchOp{
+		{Op: m.PatchAddTask, Task: &m.Task{ID: "DEPS"}},
+		{Op: m.PatchAddEdge, Edge: &m.Edge{From: "DEPS", To: "B", Type: "infrastructure", IsHard: true, Confidence: 1}},
+		{Op: m.PatchModifyResource, Resource: "npm", Spec: &m.ResourceSpec{Capacity: 2}},
+	}}
+	out, res, err := ApplyPatch(coord, p)
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if len(out.Graph.Nodes) != 3 || len(out.Graph.Edges) != 2 || out.Config.Resources.Catalog["npm"].Capacity != 2 {
+		t.Fatalf("unexpected patched coordinator %+v", out)
+	}
+	if len(coord.Graph.Nodes) != 2 || len(coord.Graph.Edges) != 1 || coord.Config.Resources.Catalog != nil {
+		t.Fatal("input coordinator must not be modified")
+	}
+	if res.GraphHash == "" || res.GraphHash == before {
+		t.Fatalf("expected a new graph hash, got %q", res.GraphHash)
+	}
+	if len(res.Tasks) != 1 || len(res.Edges) != 1 || len(res.Resources) != 1 {
+		t.Fatalf("unexpected result %+v", res)
+	}
+}
+
+func TestApplyPatchRejects(t *testing.T) {
+	coord := chainCoordinator([]string{"A", "B"}, [][2]string{{"A", "B"}})
+	hard := func(from, to string) *m.Edge {
+		return &m.Edge{From: from, To: to, Type: "technical", IsHard: true, Confidence: 1}
+	}
+	cases := map[string]m.Patch{
+		"missing id":     {Ops: []m.PatchOp{{Op: m.PatchAddTask, Task: &m.Task{ID: "C"}}}},
+		"duplicate task": {ID: "p", Ops: []m.PatchOp{{Op: m.PatchAddTask, Task: &m.Task{ID: "A"}}}},
+		"unknown task":   {ID: "p", Ops: []m.PatchOp{{Op: m.PatchAddEdge, Edge: hard("A", "Z")}}},
+		"cycle":          {ID: "p", Ops: []m.PatchOp{{Op: m.PatchAddEdge, Edge: hard("B", "A")}}},
+		"bad capacity":   {ID: "p", Ops: []m.PatchOp{{Op: m.PatchModifyResource, Resource: "db", Spec: &m.ResourceSpec{}}}},
+		"unknown op":     {ID: "p", Ops: []m.PatchOp{{Op: "remove_edge", Edge: hard("A", "B")}}},
+	}
+	for name, p := range cases {
+		if _, _, err := ApplyPatch(coord, p); !errors.Is(err, ErrPatchRejected) {
+			t.Errorf("%s: expected ErrPatchRejected, got %v", name, err)
+		}
+	}
+}
+
+func TestApplyPatchKeepsQuotaNeedsSatisfiable(t *testing.T) {
+	coord := chainCoordinator([]string{"A"}, nil)
+	coord.Config.Resources.Catalog = map[string]m.ResourceSpec{"db": {Capacity: 4}}
+	coord.Graph.Nodes[0].Resources.Limited = []m.ResourceNeed{{Name: "db", Units: 3}}
+	p := m.Patch{ID: "shrink", Ops: []m.PatchOp{{Op: m.PatchModifyResource, Resource: "db", Spec: &m.ResourceSpec{Capacity: 2}}}}
+	if _, _, err := ApplyPatch(coord, p); !errors.Is(err, ErrPatchRejected) {
+		t.Fatalf("expected shrink below a task's need to be rejected, got %v", err)
+	}
+}
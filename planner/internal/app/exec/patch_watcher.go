@@ -0,0 +1,100 @@
+package exec
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	m "github.com/james/tasks-planner/internal/model"
+)
+
+// DefaultPatchInterval is how often PatchWatcher polls when Interval is unset.
+const DefaultPatchInterval = time.Second
+
+// PatchWatcher polls Dir for *.json patch files and applies each once, in name order. Applied
+// files are renamed to *.applied and rejected ones to *.rejected so they are never retried.
+//
+// Patches should be dropped atomically: write them under another name (e.g. *.json.tmp) and
+// rename them into place. As a fallback for writers that do not, a file that fails to decode is
+// left alone while it was modified within the last poll interval, since it may still be written.
+type PatchWatcher struct {
+	Dir      string
+	Interval time.Duration
+	Apply    func(m.Patch) (PatchResult, error)
+	Report   func(file string, res PatchResult, err error)
+}
+
+// Run polls until ctx is done.
+func (w PatchWatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval())
+	defer ticker.Stop()
+	for {
+		w.Poll()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll applies every pending patch file once.
+func (w PatchWatcher) Poll() {
+	files, err := filepath.Glob(filepath.Join(w.Dir, "*.json"))
+	if err != nil {
+		return
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		res, err := w.applyFile(file)
+		if errors.Is(err, errUndecodable) && w.recent(file) {
+			continue
+		}
+		suffix := ".applied"
+		if err != nil {
+			suffix = ".rejected"
+		}
+		if rerr := os.Rename(file, file+suffix); rerr != nil && err == nil {
+			err = fmt.Errorf("patch applied but not archived: %w", rerr)
+		}
+		if w.Report != nil {
+			w.Report(file, res, err)
+		}
+	}
+}
+
+func (w PatchWatcher) interval() time.Duration {
+	if w.Interval <= 0 {
+		return DefaultPatchInterval
+	}
+	return w.Interval
+}
+
+// recent reports whether file was modified within the poll interval.
+func (w PatchWatcher) recent(file string) bool {
+	info, err := os.Stat(file)
+	return err == nil && time.Since(info.ModTime()) < w.interval()
+}
+
+// errUndecodable marks a patch file that does not decode, which may just be half written.
+var errUndecodable = errors.New("decode patch")
+
+func (w PatchWatcher) applyFile(file string) (PatchResult, error) {
+	raw, err := os.ReadFile(file)
+	if err != nil {
+		return PatchResult{}, err
+	}
+	var p m.Patch
+	dec := json.NewDecoder(strings.NewReader(string(raw)))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&p); err != nil {
+		return PatchResult{}, fmt.Errorf("%w %s: %w", errUndecodable, filepath.Base(file), err)
+	}
+	return w.Apply(p)
+}
@@ -0,0 +1,83 @@
+package exec
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	m "github.com/james/tasks-planner/internal/model"
+)
+
+func TestPatchWatcherAppliesEachFileOnce(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, body string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("01-good.json", `{"id":"good","ops":[{"op":"add_task","task":{"id":"DEPS"}}]}`)
+	write("02-bad.json", `{"id":"bad","ops":[{"op":"drop_edge"}]}`)
+	coord := chainCoordinator([]string{"A"}, nil)
+	var applied []string
+	reports := map[string]error{}
+	w := PatchWatcher{
+		Dir: dir,
+		Apply: func(p m.Patch) (PatchResult, error) {
+			out, res, err := ApplyPatch(coord, p)
+			if err == nil {
+				coord = out
+				applied = append(applied, p.ID)
+			}
+			return res, err
+		},
+		Report: func(file string, res PatchResult, err error) { reports[filepath.Base(file)] = err },
+	}
+	w.Poll()
+	w.Poll()
+	if len(applied) != 1 || applied[0] != "good" || len(coord.Graph.Nodes) != 2 {
+		t.Fatalf("expected only the good patch applied once, got %v", applied)
+	}
+	if reports["01-good.json"] != nil || reports["02-bad.json"] == nil {
+		t.Fatalf("unexpected reports %v", reports)
+	}
+	for _, name := range []string{"01-good.json.applied", "02-bad.json.rejected"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Fatalf("expected %s: %v", name, err)
+		}
+	}
+}
+
+func TestPatchWatcherWaitsForTruncatedFile(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "01-partial.json")
+	if err := os.WriteFile(file, []byte(`{"id":"partial","ops":[{"op":"add_ta`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var reports []error
+	w := PatchWatcher{
+		Dir:      dir,
+		Interval: time.Minute,
+		Apply:    func(p m.Patch) (PatchResult, error) { return PatchResult{}, nil },
+		Report:   func(file string, res PatchResult, err error) { reports = append(reports, err) },
+	}
+	w.Poll()
+	if len(reports) != 0 {
+		t.Fatalf("expected a freshly written partial file to be left alone, got %v", reports)
+	}
+	if _, err := os.Stat(file); err != nil {
+		t.Fatalf("partial file moved: %v", err)
+	}
+	// Still undecodable a full interval later: the writer is gone, so it is rejected.
+	old := time.Now().Add(-2 * time.Minute)
+	if err := os.Chtimes(file, old, old); err != nil {
+		t.Fatal(err)
+	}
+	w.Poll()
+	if len(reports) != 1 || reports[0] == nil {
+		t.Fatalf("expected the stale partial file rejected, got %v", reports)
+	}
+	if _, err := os.Stat(file + ".rejected"); err != nil {
+		t.Fatalf("expected %s.rejected: %v", filepath.Base(file), err)
+	}
+}
@@ -0,0 +1,166 @@
+package exec
+
+import (
+	"fmt"
+	"math/bits"
+	"time"
+
+	m "github.com/james/tasks-planner/internal/model"
+	"github.com/james/tasks-planner/internal/planner/dag"
+)
+
+// WeightedPrioritizer implements the frontier heuristic of the formal spec: shallower depth,
+// wider downstream unblocking, higher evidence confidence and lower rollback cost first, plus an
+// aging term so low-scoring tasks cannot starve. See m.PriorityWeights for the formula.
+type WeightedPrioritizer struct {
+	Weights m.PriorityWeights
+
+	depth      map[string]float64
+	downstream map[string]float64
+}
+
+// NewWeightedPrioritizer precomputes normalized depth (from planner/dag) and transitive
+// downstream task counts for coord, weighted by coord.Config.Policies.Priority.
+func NewWeightedPrioritizer(coord m.Coordinator) (*WeightedPrioritizer, error) {
+	w := coord.Config.Policies.Priority
+	if w == (m.PriorityWeights{}) {
+		w = m.DefaultPriorityWeights()
+	}
+	p := &WeightedPrioritizer{Weights: w, depth: map[string]float64{}, downstream: map[string]float64{}}
+	if len(coord.Graph.Nodes) == 0 {
+		return p, nil
+	}
+	df, err := dag.Build(coord.Graph.Nodes, coord.Graph.Edges, 0)
+	if err != nil {
+		return nil, fmt.Errorf("priority: %w", err)
+	}
+	maxDepth := 0
+	for _, n := range df.Nodes {
+		if n.Depth > maxDepth {
+			maxDepth = n.Depth
+		}
+	}
+	for _, n := range df.Nodes {
+		if maxDepth > 0 {
+			p.depth[n.ID] = float64(n.Depth) / float64(maxDepth)
+		}
+	}
+
+	idx := make(map[string]int, len(coord.Graph.Nodes))
+	for i, t := range coord.Graph.Nodes {
+		idx[t.ID] = i
+	}
+	adj := make([][]int, len(coord.Graph.Nodes))
+	for _, e := range coord.Graph.Edges {
+		u, okFrom := idx[e.From]
+		v, okTo := idx[e.To]
+		if isPrecedence(e) && okFrom && okTo {
+			adj[u] = append(adj[u], v)
+		}
+	}
+	counts := downstreamCounts(adj, topoIndices(adj))
+	maxDown := 0
+	for _, n := range counts {
+		maxDown = max(maxDown, n)
+	}
+	if maxDown > 0 {
+		for i, t := range coord.Graph.Nodes {
+			p.downstream[t.ID] = float64(counts[i]) / float64(maxDown)
+		}
+	}
+	return p, nil
+}
+
+// downstreamBlock is the number of target positions whose reachability downstreamCounts tracks
+// per pass; it caps the bitset memory at n*downstreamBlock/8 bytes.
+const downstreamBlock = 4096
+
+// downstreamCounts returns how many nodes each node of adj transitively reaches; topo must be a
+// topological order of adj. As in planner/dag's transitive reduction, reachability is a bitset
+// over topo positions built in one reverse-topological pass per block of target positions, so the
+// whole computation takes O(e*n/64) instead of a search per node.
+func downstreamCounts(adj [][]int, topo []int) []int {
+	n := len(topo)
+	pos := make([]int, n)
+	for p, u := range topo {
+		pos[u] = p
+	}
+	words := (min(n, downstreamBlock) + 63) / 64
+	reach := make([]uint64, n*words)
+	counts := make([]int, n)
+	for lo := 0; lo < n; lo += downstreamBlock {
+		hi := min(lo+downstreamBlock, n)
+		clear(reach)
+		// A node only reaches positions after its own, so the last one in the block reaches none of it.
+		for p := hi - 2; p >= 0; p-- {
+			u := topo[p]
+			row := reach[p*words : (p+1)*words]
+			for _, v := range adj[u] {
+				q := pos[v]
+				if q >= hi {
+					continue
+				}
+				for i, w := range reach[q*words : (q+1)*words] {
+					row[i] |= w
+				}
+				if q >= lo {
+					row[(q-lo)/64] |= 1 << ((q - lo) % 64)
+				}
+			}
+			for _, w := range row {
+				counts[u] += bits.OnesCount64(w)
+			}
+		}
+	}
+	return counts
+}
+
+// topoIndices returns a topological order of the acyclic adj (Kahn, lowest index first).
+func topoIndices(adj [][]int) []int {
+	indeg := make([]int, len(adj))
+	for _, vs := range adj {
+		for _, v := range vs {
+			indeg[v]++
+		}
+	}
+	var queue []int
+	for u, d := range indeg {
+		if d == 0 {
+			queue = append(queue, u)
+		}
+	}
+	out := make([]int, 0, len(adj))
+	for len(queue) > 0 {
+		u := queue[0]
+		queue = queue[1:]
+		out = append(out, u)
+		for _, v := range adj[u] {
+			if indeg[v]--; indeg[v] == 0 {
+				queue = append(queue, v)
+			}
+		}
+	}
+	return out
+}
+
+// Priority implements Prioritizer.
+func (p *WeightedPrioritizer) Priority(task m.Task, waited time.Duration) float64 {
+	w := p.Weights
+	score := w.FanOut*p.downstream[task.ID] - w.Depth*p.depth[task.ID]
+	score += w.Confidence * evidenceConfidence(task)
+	if !task.Compensation.Idempotent {
+		score -= w.RollbackCost
+	}
+	return score + w.AgingPerMinute*waited.Minutes()
+}
+
+func evidenceConfidence(task m.Task) float64 {
+	if len(task.Evidence) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, e := range task.Evidence {
+		sum += e.Confidence
+	}
+	return sum / float64(len(task.Evidence))
+}
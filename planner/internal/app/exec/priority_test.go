@@ -0,0 +1,109 @@
+package exec
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	m "github.com/james/tasks-planner/internal/model"
+)
+
+func TestWeightedPrioritizerStartsCriticalPathFirst(t *testing.T) {
+	// A heads a three-task chain; B and C are leaves. Readiness order would start B first.
+	coord := chainCoordinator([]string{"B", "C", "A", "A2", "A3"}, [][2]string{{"A", "A2"}, {"A2", "A3"}})
+	for i := range coord.Graph.Nodes {
+		coord.Graph.Nodes[i].Compensation.Idempotent = true
+	}
+	coord.Config.Policies.ConcurrencyMax = 1
+	s, err := NewScheduler(coord)
+	if err != nil {
+		t.Fatalf("new scheduler: %v", err)
+	}
+	p, err := NewWeightedPrioritizer(coord)
+	if err != nil {
+		t.Fatalf("prioritizer: %v", err)
+	}
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	s.SetPrioritizer(p, clock.Now)
+	if got := taskIDs(s.Next()); len(got) != 1 || got[0] != "A" {
+		t.Fatalf("expected the chain head first, got %v", got)
+	}
+}
+
+func TestWeightedPrioritizerTerms(t *testing.T) {
+	coord := chainCoordinator([]string{"A", "B"}, nil)
+	p, err := NewWeightedPrioritizer(coord)
+	if err != nil {
+		t.Fatalf("prioritizer: %v", err)
+	}
+	if p.Weights != m.DefaultPriorityWeights() {
+		t.Fatalf("expected default weights, got %+v", p.Weights)
+	}
+	safe := m.Task{ID: "A", Evidence: []m.Evidence{{Confidence: 0.9}, {Confidence: 0.7}}}
+	safe.Compensation.Idempotent = true
+	risky := m.Task{ID: "B"}
+	if p.Priority(safe, 0) <= p.Priority(risky, 0) {
+		t.Fatal("confident idempotent task should outrank an unevidenced one with a rollback cost")
+	}
+	if p.Priority(risky, 30*time.Minute) <= p.Priority(safe, 0) {
+		t.Fatal("aging should eventually lift a waiting task above fresh ones")
+	}
+}
+
+func TestSchedulerAgingOvertakesHigherPriority(t *testing.T) {
+	coord := chainCoordinator([]string{"LOW", "HIGH"}, nil)
+	coord.Config.Policies.ConcurrencyMax = 1
+	s, err := NewScheduler(coord)
+	if err != nil {
+		t.Fatalf("new scheduler: %v", err)
+	}
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	s.SetPrioritizer(agingOnly{"HIGH": 10}, clock.Now)
+	if got := taskIDs(s.Next()); got[0] != "HIGH" {
+		t.Fatalf("expected HIGH first, got %v", got)
+	}
+	if err := s.Requeue("HIGH", nil); err != nil {
+		t.Fatalf("requeue: %v", err)
+	}
+	clock.Advance(time.Hour)
+	if got := taskIDs(s.Next()); got[0] != "LOW" {
+		t.Fatalf("expected LOW after waiting an hour, got %v", got)
+	}
+}
+
+// agingOnly scores a fixed base per task plus one point per minute ready.
+type agingOnly map[string]float64
+
+func (a agingOnly) Priority(task m.Task, waited time.Duration) float64 {
+	return a[task.ID] + waited.Minutes()
+}
+
+func TestDownstreamCountsMatchSearch(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	// Spans more than one reachability block, with duplicate edges and a shuffled topo order.
+	n := downstreamBlock + 300
+	perm := rng.Perm(n)
+	adj := make([][]int, n)
+	for p := 0; p < n-1; p++ {
+		for k := rng.Intn(3); k > 0; k-- {
+			q := p + 1 + rng.Intn(min(30, n-p-1))
+			adj[perm[p]] = append(adj[perm[p]], perm[q], perm[q])
+		}
+	}
+	got := downstreamCounts(adj, topoIndices(adj))
+	for u := 0; u < n; u += 97 {
+		seen := map[int]bool{}
+		stack := append([]int(nil), adj[u]...)
+		for len(stack) > 0 {
+			v := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if !seen[v] {
+				seen[v] = true
+				stack = append(stack, adj[v]...)
+			}
+		}
+		if got[u] != len(seen) {
+			t.Fatalf("node %d: downstream %d, want %d", u, got[u], len(seen))
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package exec
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	m "github.com/james/tasks-planner/internal/model"
+)
+
+// ValidateProfiles checks that every profile only overrides resources present in the catalog and
+// never assigns a negative capacity.
+func ValidateProfiles(coord m.Coordinator) error {
+	var problems []string
+	for profile, caps := range coord.Config.Resources.Profiles {
+		for name, capacity := range caps {
+			if _, ok := coord.Config.Resources.Catalog[name]; !ok {
+				problems = append(problems, fmt.Sprintf("profile %q references unknown resource %q", profile, name))
+			}
+			if capacity < 0 {
+				problems = append(problems, fmt.Sprintf("profile %q sets negative capacity %d for %q", profile, capacity, name))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("profiles: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// ApplyProfile validates all profiles and returns a copy of coord whose catalog capacities are
+// overlaid with the named profile. An empty name leaves the catalog unchanged.
+func ApplyProfile(coord m.Coordinator, name string) (m.Coordinator, error) {
+	if err := ValidateProfiles(coord); err != nil {
+		return coord, err
+	}
+	if name == "" {
+		return coord, nil
+	}
+	caps, ok := coord.Config.Resources.Profiles[name]
+	if !ok {
+		available := make([]string, 0, len(coord.Config.Resources.Profiles))
+		for p := range coord.Config.Resources.Profiles {
+			available = append(available, p)
+		}
+		sort.Strings(available)
+		return coord, fmt.Errorf("unknown profile %q (available: %s)", name, strings.Join(available, ", "))
+	}
+	catalog := make(map[string]m.ResourceSpec, len(coord.Config.Resources.Catalog))
+	for res, spec := range coord.Config.Resources.Catalog {
+		if capacity, ok := caps[res]; ok {
+			spec.Capacity = capacity
+		}
+		catalog[res] = spec
+	}
+	coord.Config.Resources.Catalog = catalog
+	return coord, nil
+}
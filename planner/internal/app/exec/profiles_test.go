@@ -0,0 +1,59 @@
+package exec
+
+import (
+	"strings"
+	"testing"
+
+	m "github.com/james/tasks-planner/internal/model"
+)
+
+func profileCoordinator() m.Coordinator {
+	var coord m.Coordinator
+	coord.Config.Resources.Catalog = map[string]m.ResourceSpec{
+		"ci_runners": {Capacity: 2},
+		"test_db":    {Capacity: 1, Mode: ResourceModeExclusive},
+	}
+	coord.Config.Resources.Profiles = map[string]map[string]int{
+		"default": {},
+		"ci":      {"ci_runners": 8, "test_db": 4},
+	}
+	return coord
+}
+
+func TestApplyProfileOverlaysCapacities(t *testing.T) {
+	base := profileCoordinator()
+	got, err := ApplyProfile(base, "ci")
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if got.Config.Resources.Catalog["ci_runners"].Capacity != 8 || got.Config.Resources.Catalog["test_db"].Capacity != 4 {
+		t.Fatalf("capacities not overlaid: %+v", got.Config.Resources.Catalog)
+	}
+	if got.Config.Resources.Catalog["test_db"].Mode != ResourceModeExclusive {
+		t.Fatalf("overlay must keep other spec fields")
+	}
+	if base.Config.Resources.Catalog["ci_runners"].Capacity != 2 {
+		t.Fatalf("input catalog was mutated")
+	}
+}
+
+func TestApplyProfileUnknownProfile(t *testing.T) {
+	_, err := ApplyProfile(profileCoordinator(), "prod")
+	if err == nil || !strings.Contains(err.Error(), "available: ci, default") {
+		t.Fatalf("expected unknown profile error listing options, got %v", err)
+	}
+}
+
+func TestValidateProfilesRejectsUnknownResources(t *testing.T) {
+	coord := profileCoordinator()
+	coord.Config.Resources.Profiles["local"] = map[string]int{"gpu": 1, "ci_runners": -1}
+	_, err := ApplyProfile(coord, "")
+	if err == nil {
+		t.Fatalf("expected validation error")
+	}
+	for _, want := range []string{`unknown resource "gpu"`, "negative capacity"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected %q in %v", want, err)
+		}
+	}
+}
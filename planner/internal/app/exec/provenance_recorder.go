@@ -0,0 +1,188 @@
+package exec
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/james/tasks-planner/internal/acceptance"
+	m "github.com/james/tasks-planner/internal/model"
+	"github.com/james/tasks-planner/internal/provenance"
+)
+
+// ProvenanceRecorder writes every dispatch, completion, retry, compensation rollback and requeue to
+// the provenance ledger. Artifacts are the files named by a task's file-based acceptance checks;
+// their hashes are taken at dispatch and again at completion so the ledger records what the task
+// actually changed.
+type ProvenanceRecorder struct {
+	Ledger *provenance.Ledger
+	// Dir resolves relative artifact paths (matches the acceptance check directory).
+	Dir string
+	// TelemetryDir, when set, is where raw task telemetry is kept; records point at it.
+	TelemetryDir string
+
+	mu      sync.Mutex
+	started map[string]time.Time
+	before  map[string]map[string]string
+}
+
+// Record implements Recorder.
+func (p *ProvenanceRecorder) Record(ev Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.started == nil {
+		p.started = map[string]time.Time{}
+		p.before = map[string]map[string]string{}
+	}
+	rec := provenance.Record{Time: formatTime(ev.Time), TaskID: ev.TaskID, WorkerID: ev.WorkerID, CheckpointID: ev.CheckpointID}
+	switch ev.State {
+	case TaskRunning:
+		rec.Kind = provenance.KindDispatch
+		rec.StartedAt = rec.Time
+		p.started[ev.TaskID] = ev.Time
+		p.before[ev.TaskID] = p.snapshot(ev.Task)
+	case TaskDone, TaskFailed:
+		rec.Kind = provenance.KindComplete
+		if ev.State == TaskFailed {
+			rec.Kind = provenance.KindFail
+		}
+		if start, ok := p.started[ev.TaskID]; ok {
+			rec.StartedAt = formatTime(start)
+		}
+		rec.FinishedAt = rec.Time
+		code := exitCode(ev.Err)
+		rec.ExitCode = &code
+		if ev.Err != nil {
+			rec.Error = ev.Err.Error()
+		}
+		rec.Artifacts = diffArtifacts(p.before[ev.TaskID], p.snapshot(ev.Task))
+		if p.TelemetryDir != "" {
+			if path := TelemetryPath(p.TelemetryDir, ev.TaskID); fileHash(path) != "" {
+				rec.Telemetry = path
+			}
+		}
+		delete(p.started, ev.TaskID)
+		delete(p.before, ev.TaskID)
+	case TaskRolledBack:
+		rec.Kind = provenance.KindRollback
+		code := exitCode(ev.Err)
+		rec.ExitCode = &code
+		if ev.Err != nil {
+			rec.Error = ev.Err.Error()
+		}
+		rec.Data = map[string]any{"rollback_cmd": ev.Task.Compensation.RollbackCmd}
+	case TaskReady, TaskRetrying:
+		rec.Kind = provenance.KindRequeue
+		if ev.State == TaskRetrying {
+			rec.Kind = provenance.KindRetry
+			code := exitCode(ev.Err)
+			rec.ExitCode = &code
+			rec.Data = map[string]any{"next_attempt": float64(ev.Attempt)}
+		}
+		if start, ok := p.started[ev.TaskID]; ok {
+			rec.StartedAt = formatTime(start)
+		}
+		if ev.Err != nil {
+			rec.Error = ev.Err.Error()
+		}
+		delete(p.started, ev.TaskID)
+		delete(p.before, ev.TaskID)
+	default:
+		return nil
+	}
+	_, err := p.Ledger.Append(rec)
+	return err
+}
+
+// RecordPatch implements PatchRecorder: the patch and the graph hash it produces are appended
+// before the patch takes effect.
+func (p *ProvenanceRecorder) RecordPatch(at time.Time, patch m.Patch, res PatchResult, checkpointID string) error {
+	data, err := ledgerData(map[string]any{"patch": patch, "result": res})
+	if err != nil {
+		return err
+	}
+	rec := provenance.Record{Kind: provenance.KindPatch, Time: formatTime(at), CheckpointID: checkpointID, Data: data}
+	_, err = p.Ledger.Append(rec)
+	return err
+}
+
+// ledgerData round-trips v through JSON so the record hashes identically once read back.
+func ledgerData(v map[string]any) (map[string]any, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("encode ledger data: %w", err)
+	}
+	var out map[string]any
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, fmt.Errorf("decode ledger data: %w", err)
+	}
+	return out, nil
+}
+
+func (p *ProvenanceRecorder) snapshot(task m.Task) map[string]string {
+	out := map[string]string{}
+	for _, c := range task.AcceptanceChecks {
+		switch c.Type {
+		case acceptance.TypeFileExists, acceptance.TypeFileContains, acceptance.TypeJSONPath:
+		default:
+			continue
+		}
+		if c.Path == "" {
+			continue
+		}
+		out[c.Path] = fileHash(p.resolve(c.Path))
+	}
+	return out
+}
+
+func (p *ProvenanceRecorder) resolve(path string) string {
+	if filepath.IsAbs(path) || p.Dir == "" {
+		return path
+	}
+	return filepath.Join(p.Dir, path)
+}
+
+func diffArtifacts(before, after map[string]string) []provenance.ArtifactChange {
+	var out []provenance.ArtifactChange
+	for path, h := range after {
+		if before[path] != h {
+			out = append(out, provenance.ArtifactChange{Path: path, Before: before[path], After: h})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	return out
+}
+
+func fileHash(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// exitCode maps a runner error onto a process exit code: 0 for success, the worker's exit status
+// when available, and -1 otherwise.
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
@@ -0,0 +1,236 @@
+package exec
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	m "github.com/james/tasks-planner/internal/model"
+	"github.com/james/tasks-planner/internal/provenance"
+)
+
+func TestProvenanceRecorderLedgersRun(t *testing.T) {
+	dir := t.TempDir()
+	ledger, err := provenance.Open(filepath.Join(dir, "provenance.jsonl"))
+	if err != nil {
+		t.Fatalf("open ledger: %v", err)
+	}
+	coord := chainCoordinator([]string{"A", "B"}, [][2]string{{"A", "B"}})
+	coord.Graph.Nodes[0].AcceptanceChecks = []m.AcceptanceCheck{{Type: "file_exists", Path: "out.txt"}}
+	rt := &Runtime{
+		Runner: func(ctx context.Context, task m.Task) error {
+			if task.ID == "A" {
+				return os.WriteFile(filepath.Join(dir, "out.txt"), []byte("hi"), 0o644)
+			}
+			return errors.New("boom")
+		},
+		Recorder: &ProvenanceRecorder{Ledger: ledger, Dir: dir},
+	}
+	if err := rt.Init(context.Background(), coord); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	if err := rt.Run(context.Background()); !errors.Is(err, ErrTasksFailed) {
+		t.Fatalf("expected ErrTasksFailed, got %v", err)
+	}
+	_ = ledger.Close()
+
+	f, err := os.Open(ledger.Path())
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer f.Close()
+	recs, err := provenance.ReadAll(f)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	kinds := []string{provenance.KindDispatch, provenance.KindComplete, provenance.KindDispatch, provenance.KindFail}
+	if len(recs) != len(kinds) {
+		t.Fatalf("expected %d records, got %d", len(kinds), len(recs))
+	}
+	for i, want := range kinds {
+		if recs[i].Kind != want {
+			t.Fatalf("record %d: kind %s, want %s", i, recs[i].Kind, want)
+		}
+	}
+	done := recs[1]
+	if done.ExitCode == nil || *done.ExitCode != 0 || done.StartedAt == "" || done.FinishedAt == "" {
+		t.Fatalf("incomplete completion record %+v", done)
+	}
+	if len(done.Artifacts) != 1 || done.Artifacts[0].Path != "out.txt" || done.Artifacts[0].Before != "" || done.Artifacts[0].After == "" {
+		t.Fatalf("expected out.txt artifact change, got %+v", done.Artifacts)
+	}
+	if recs[3].Error == "" || *recs[3].ExitCode != -1 {
+		t.Fatalf("failure record missing error: %+v", recs[3])
+	}
+	if _, err := provenance.VerifyFile(ledger.Path()); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+}
+
+func TestProvenanceRecorderLedgersPatch(t *testing.T) {
+	ledger, err := provenance.Open(filepath.Join(t.TempDir(), "provenance.jsonl"))
+	if err != nil {
+		t.Fatalf("open ledger: %v", err)
+	}
+	clock := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	rt := &Runtime{
+		Runner:   func(ctx context.Context, task m.Task) error { return nil },
+		Recorder: &ProvenanceRecorder{Ledger: ledger},
+		Now:      func() time.Time { return clock },
+	}
+	if err := rt.Init(context.Background(), chainCoordinator([]string{"A"}, nil)); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	res, err := rt.ApplyPatch(m.Patch{ID: "p1", Reason: "missing deps", Ops: []m.PatchOp{{Op: m.PatchAddTask, Task: &m.Task{ID: "DEPS"}}}})
+	if err != nil {
+		t.Fatalf("patch: %v", err)
+	}
+	_ = ledger.Close()
+
+	f, err := os.Open(ledger.Path())
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer f.Close()
+	recs, err := provenance.ReadAll(f)
+	if err != nil || len(recs) != 1 || recs[0].Kind != provenance.KindPatch {
+		t.Fatalf("expected one patch record, got %+v (%v)", recs, err)
+	}
+	if recs[0].Time != "2024-05-01T12:00:00Z" {
+		t.Fatalf("expected the patch stamped by the runtime clock, got %s", recs[0].Time)
+	}
+	result, _ := recs[0].Data["result"].(map[string]any)
+	if result["graph_hash"] != res.GraphHash {
+		t.Fatalf("expected graph hash %s recorded, got %v", res.GraphHash, recs[0].Data)
+	}
+	if _, err := provenance.VerifyFile(ledger.Path()); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+}
+
+func TestProvenanceRecorderLedgersCompensation(t *testing.T) {
+	ledger, err := provenance.Open(filepath.Join(t.TempDir(), "provenance.jsonl"))
+	if err != nil {
+		t.Fatalf("open ledger: %v", err)
+	}
+	coord := chainCoordinator([]string{"A"}, nil)
+	coord.Graph.Nodes[0].Compensation.RollbackCmd = "undo A"
+	runs := 0
+	rt := &Runtime{
+		Runner: func(ctx context.Context, task m.Task) error {
+			runs++
+			if runs == 1 {
+				return errors.New("half applied")
+			}
+			return nil
+		},
+		Compensator: func(ctx context.Context, task m.Task) error { return nil },
+		Recorder:    &ProvenanceRecorder{Ledger: ledger},
+	}
+	if err := rt.Init(context.Background(), coord); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	if err := rt.Run(context.Background()); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	_ = ledger.Close()
+
+	f, err := os.Open(ledger.Path())
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer f.Close()
+	recs, err := provenance.ReadAll(f)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	kinds := []string{provenance.KindDispatch, provenance.KindRollback, provenance.KindRequeue, provenance.KindDispatch, provenance.KindComplete}
+	if len(recs) != len(kinds) {
+		t.Fatalf("expected %d records, got %+v", len(kinds), recs)
+	}
+	for i, want := range kinds {
+		if recs[i].Kind != want {
+			t.Fatalf("record %d: kind %s, want %s", i, recs[i].Kind, want)
+		}
+	}
+	if recs[1].Data["rollback_cmd"] != "undo A" || recs[2].Error != "half applied" {
+		t.Fatalf("incomplete compensation records %+v %+v", recs[1], recs[2])
+	}
+}
+
+func TestRuntimeCompensationLedgersRollbackOrder(t *testing.T) {
+	ledger, err := provenance.Open(filepath.Join(t.TempDir(), "provenance.jsonl"))
+	if err != nil {
+		t.Fatalf("open ledger: %v", err)
+	}
+	coord := chainCoordinator([]string{"A", "B", "C"}, [][2]string{{"A", "B"}, {"B", "C"}})
+	for i := range coord.Graph.Nodes {
+		coord.Graph.Nodes[i].Compensation.RollbackCmd = "undo " + coord.Graph.Nodes[i].ID
+	}
+	coord.Graph.Nodes[0].Resources.Exclusive = []string{"db"}
+	coord.Graph.Nodes[2].Resources.Exclusive = []string{"db"}
+	runs := map[string]int{}
+	var rollbacks []string
+	rt := &Runtime{
+		Runner: func(ctx context.Context, task m.Task) error {
+			runs[task.ID]++
+			if task.ID == "C" && runs["C"] == 1 {
+				return errors.New("migration half applied")
+			}
+			return nil
+		},
+		Compensator: func(ctx context.Context, task m.Task) error {
+			if _, ok := ctx.Deadline(); !ok {
+				t.Errorf("rollback of %s has no timeout", task.ID)
+			}
+			rollbacks = append(rollbacks, task.ID)
+			return nil
+		},
+		Recorder: &ProvenanceRecorder{Ledger: ledger},
+	}
+	if err := rt.Init(context.Background(), coord); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	if err := rt.Run(context.Background()); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	_ = ledger.Close()
+
+	// C shares db with A, and B depends on A, so all three are undone, dependents first.
+	if want := []string{"C", "B", "A"}; !reflect.DeepEqual(rollbacks, want) {
+		t.Fatalf("rollbacks = %v, want %v", rollbacks, want)
+	}
+	if want := map[string]int{"A": 2, "B": 2, "C": 2}; !reflect.DeepEqual(runs, want) {
+		t.Fatalf("runs = %v, want %v", runs, want)
+	}
+	f, err := os.Open(ledger.Path())
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer f.Close()
+	recs, err := provenance.ReadAll(f)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	var got []string
+	for _, rec := range recs {
+		got = append(got, rec.TaskID+":"+rec.Kind)
+	}
+	want := []string{
+		"A:" + provenance.KindDispatch, "A:" + provenance.KindComplete,
+		"B:" + provenance.KindDispatch, "B:" + provenance.KindComplete,
+		"C:" + provenance.KindDispatch,
+		"C:" + provenance.KindRollback, "B:" + provenance.KindRollback, "A:" + provenance.KindRollback,
+		"C:" + provenance.KindRequeue,
+		"A:" + provenance.KindDispatch, "A:" + provenance.KindComplete,
+		"B:" + provenance.KindDispatch, "B:" + provenance.KindComplete,
+		"C:" + provenance.KindDispatch, "C:" + provenance.KindComplete,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ledger = %v\nwant %v", got, want)
+	}
+}
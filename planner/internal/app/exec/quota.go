@@ -0,0 +1,150 @@
+package exec
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	m "github.com/james/tasks-planner/internal/model"
+)
+
+// ResourceModeExclusive marks a catalog entry whose every holder is treated as a writer.
+const ResourceModeExclusive = "exclusive"
+
+// QuotaManager governs Task.Resources.Limited against the resource catalog. A task is admitted
+// only while the units held per resource stay within capacity; write access additionally
+// requires that nobody else holds the resource, while reads share it.
+type QuotaManager struct {
+	catalog map[string]m.ResourceSpec
+	used    map[string]int
+	writer  map[string]string
+	holders map[string]int
+	holds   map[string][]m.ResourceNeed
+}
+
+// NewQuotaManager validates every limited need against the catalog so no task can wait forever on
+// a resource that does not exist or is smaller than the request.
+func NewQuotaManager(coord m.Coordinator) (*QuotaManager, error) {
+	q := &QuotaManager{
+		catalog: coord.Config.Resources.Catalog,
+		used:    map[string]int{},
+		writer:  map[string]string{},
+		holders: map[string]int{},
+		holds:   map[string][]m.ResourceNeed{},
+	}
+	var problems []string
+	for _, t := range coord.Graph.Nodes {
+		for _, need := range q.needs(t) {
+			spec, ok := q.catalog[need.Name]
+			switch {
+			case !ok:
+				problems = append(problems, fmt.Sprintf("task %s needs unknown resource %q", t.ID, need.Name))
+			case spec.Capacity <= 0:
+				problems = append(problems, fmt.Sprintf("resource %q has no capacity (task %s)", need.Name, t.ID))
+			case need.Units > spec.Capacity:
+				problems = append(problems, fmt.Sprintf("task %s needs %d units of %q (capacity %d)", t.ID, need.Units, need.Name, spec.Capacity))
+			}
+			if need.Access != "" && need.Access != m.AccessRead && need.Access != m.AccessWrite {
+				problems = append(problems, fmt.Sprintf("task %s: invalid access %q for %q", t.ID, need.Access, need.Name))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return nil, fmt.Errorf("quota: %s", strings.Join(problems, "; "))
+	}
+	return q, nil
+}
+
+// needs merges duplicate entries per resource, defaulting units to 1 and letting write win.
+func (q *QuotaManager) needs(task m.Task) []m.ResourceNeed {
+	byName := map[string]*m.ResourceNeed{}
+	var names []string
+	for _, need := range task.Resources.Limited {
+		if need.Name == "" {
+			continue
+		}
+		units := need.Units
+		if units <= 0 {
+			units = 1
+		}
+		access := strings.ToLower(strings.TrimSpace(need.Access))
+		if q.catalog[need.Name].Mode == ResourceModeExclusive {
+			access = m.AccessWrite
+		}
+		cur, ok := byName[need.Name]
+		if !ok {
+			byName[need.Name] = &m.ResourceNeed{Name: need.Name, Units: units, Access: access}
+			names = append(names, need.Name)
+			continue
+		}
+		cur.Units += units
+		if access == m.AccessWrite {
+			cur.Access = m.AccessWrite
+		}
+	}
+	sort.Strings(names)
+	out := make([]m.ResourceNeed, 0, len(names))
+	for _, n := range names {
+		out = append(out, *byName[n])
+	}
+	return out
+}
+
+// TryAcquire reserves every limited need of task, or none of them.
+func (q *QuotaManager) TryAcquire(task m.Task) bool {
+	needs := q.needs(task)
+	for _, need := range needs {
+		if !q.fits(need) {
+			return false
+		}
+	}
+	for _, need := range needs {
+		q.used[need.Name] += need.Units
+		q.holders[need.Name]++
+		if need.Access == m.AccessWrite {
+			q.writer[need.Name] = task.ID
+		}
+	}
+	if len(needs) > 0 {
+		q.holds[task.ID] = needs
+	}
+	return true
+}
+
+func (q *QuotaManager) fits(need m.ResourceNeed) bool {
+	if _, busy := q.writer[need.Name]; busy {
+		return false
+	}
+	if need.Access == m.AccessWrite && q.holders[need.Name] > 0 {
+		return false
+	}
+	return q.used[need.Name]+need.Units <= q.catalog[need.Name].Capacity
+}
+
+// Release returns every unit held by task.
+func (q *QuotaManager) Release(task m.Task) {
+	for _, need := range q.holds[task.ID] {
+		q.used[need.Name] -= need.Units
+		q.holders[need.Name]--
+		if q.writer[need.Name] == task.ID {
+			delete(q.writer, need.Name)
+		}
+	}
+	delete(q.holds, task.ID)
+}
+
+// SetCatalog swaps in a catalog changed by a hot patch. Current holdings are kept; a reduced
+// capacity applies back-pressure until usage drops below it.
+func (q *QuotaManager) SetCatalog(catalog map[string]m.ResourceSpec) {
+	q.catalog = catalog
+}
+
+// Usage returns the units currently held per catalog resource.
+func (q *QuotaManager) Usage() map[string]int {
+	out := make(map[string]int, len(q.catalog))
+	for name := range q.catalog {
+		out[name] = q.used[name]
+	}
+	return out
+}
@@ -0,0 +1,101 @@
+package exec
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	m "github.com/james/tasks-planner/internal/model"
+)
+
+func limitedTask(id string, needs ...m.ResourceNeed) m.Task {
+	t := m.Task{ID: id}
+	t.Resources.Limited = needs
+	return t
+}
+
+func quotaCoordinator(catalog map[string]m.ResourceSpec, tasks ...m.Task) m.Coordinator {
+	var coord m.Coordinator
+	coord.Config.Resources.Catalog = catalog
+	coord.Graph.Nodes = tasks
+	return coord
+}
+
+func TestQuotaManagerCapacity(t *testing.T) {
+	a := limitedTask("A", m.ResourceNeed{Name: "ci", Units: 2})
+	b := limitedTask("B", m.ResourceNeed{Name: "ci", Units: 2})
+	c := limitedTask("C", m.ResourceNeed{Name: "ci"})
+	q, err := NewQuotaManager(quotaCoordinator(map[string]m.ResourceSpec{"ci": {Capacity: 3}}, a, b, c))
+	if err != nil {
+		t.Fatalf("new quota: %v", err)
+	}
+	if !q.TryAcquire(a) {
+		t.Fatalf("A should fit")
+	}
+	if q.TryAcquire(b) {
+		t.Fatalf("B would exceed capacity")
+	}
+	if !q.TryAcquire(c) {
+		t.Fatalf("C should fit in the remaining unit")
+	}
+	if got := q.Usage()["ci"]; got != 3 {
+		t.Fatalf("expected 3 units used, got %d", got)
+	}
+	q.Release(a)
+	if !q.TryAcquire(b) {
+		t.Fatalf("B should fit after A releases")
+	}
+}
+
+func TestQuotaManagerReadWrite(t *testing.T) {
+	r1 := limitedTask("R1", m.ResourceNeed{Name: "db", Access: m.AccessRead})
+	r2 := limitedTask("R2", m.ResourceNeed{Name: "db", Access: m.AccessRead})
+	w := limitedTask("W", m.ResourceNeed{Name: "db", Access: m.AccessWrite})
+	q, err := NewQuotaManager(quotaCoordinator(map[string]m.ResourceSpec{"db": {Capacity: 4}}, r1, r2, w))
+	if err != nil {
+		t.Fatalf("new quota: %v", err)
+	}
+	if !q.TryAcquire(r1) || !q.TryAcquire(r2) {
+		t.Fatalf("readers should share")
+	}
+	if q.TryAcquire(w) {
+		t.Fatalf("writer must wait for readers")
+	}
+	q.Release(r1)
+	q.Release(r2)
+	if !q.TryAcquire(w) {
+		t.Fatalf("writer should acquire once readers leave")
+	}
+	if q.TryAcquire(r1) {
+		t.Fatalf("reader must wait for writer")
+	}
+}
+
+func TestQuotaManagerExclusiveModeAndMerging(t *testing.T) {
+	a := limitedTask("A", m.ResourceNeed{Name: "gpu", Access: m.AccessRead}, m.ResourceNeed{Name: "gpu", Units: 1})
+	b := limitedTask("B", m.ResourceNeed{Name: "gpu"})
+	q, err := NewQuotaManager(quotaCoordinator(map[string]m.ResourceSpec{"gpu": {Capacity: 4, Mode: ResourceModeExclusive}}, a, b))
+	if err != nil {
+		t.Fatalf("new quota: %v", err)
+	}
+	if got := q.needs(a); !reflect.DeepEqual(got, []m.ResourceNeed{{Name: "gpu", Units: 2, Access: m.AccessWrite}}) {
+		t.Fatalf("unexpected merged needs %+v", got)
+	}
+	if !q.TryAcquire(a) || q.TryAcquire(b) {
+		t.Fatalf("exclusive mode should serialize holders")
+	}
+}
+
+func TestQuotaManagerRejectsUnsatisfiableNeeds(t *testing.T) {
+	a := limitedTask("A", m.ResourceNeed{Name: "ci", Units: 5})
+	b := limitedTask("B", m.ResourceNeed{Name: "ghost"})
+	_, err := NewQuotaManager(quotaCoordinator(map[string]m.ResourceSpec{"ci": {Capacity: 2}}, a, b))
+	if err == nil {
+		t.Fatalf("expected validation error")
+	}
+	for _, want := range []string{"needs 5 units", `unknown resource "ghost"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected %q in %v", want, err)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package exec
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	m "github.com/james/tasks-planner/internal/model"
+)
+
+// RetryPolicyFor returns the policy governing task: its own override, else the coordinator's.
+func RetryPolicyFor(coord m.Coordinator, task m.Task) m.RetryPolicy {
+	if task.Retry != nil {
+		return *task.Retry
+	}
+	return coord.Config.Policies.Retry
+}
+
+// ValidateRetryPolicies checks the coordinator policy and every per-task override.
+func ValidateRetryPolicies(coord m.Coordinator) error {
+	var errs []error
+	if err := validateRetry(coord.Config.Policies.Retry); err != nil {
+		errs = append(errs, fmt.Errorf("retry policy: %w", err))
+	}
+	for _, t := range coord.Graph.Nodes {
+		if t.Retry == nil {
+			continue
+		}
+		if err := validateRetry(*t.Retry); err != nil {
+			errs = append(errs, fmt.Errorf("task %s retry policy: %w", t.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func validateRetry(p m.RetryPolicy) error {
+	switch {
+	case p.MaxAttempts < 0:
+		return fmt.Errorf("max_attempts %d must not be negative", p.MaxAttempts)
+	case p.BackoffSeconds < 0 || p.MaxBackoffSeconds < 0:
+		return errors.New("backoff must not be negative")
+	case p.BackoffMultiplier != 0 && p.BackoffMultiplier < 1:
+		return fmt.Errorf("backoff_multiplier %g must be at least 1", p.BackoffMultiplier)
+	}
+	return nil
+}
+
+// Retryable reports whether a failure with the given exit code (-1 when the task did not exit
+// with one) and telemetry error codes qualifies for another attempt under p.
+func Retryable(p m.RetryPolicy, exitCode int, errorCodes map[string]bool) bool {
+	if len(p.RetryableExitCodes) == 0 && len(p.RetryableErrorCodes) == 0 {
+		return true
+	}
+	for _, c := range p.RetryableExitCodes {
+		if c == exitCode {
+			return true
+		}
+	}
+	for _, c := range p.RetryableErrorCodes {
+		if errorCodes[c] {
+			return true
+		}
+	}
+	return false
+}
+
+// Backoff returns the delay before attempt retries+2, i.e. after retries earlier retries.
+func Backoff(p m.RetryPolicy, retries int) time.Duration {
+	if p.BackoffSeconds <= 0 {
+		return 0
+	}
+	mult := p.BackoffMultiplier
+	if mult == 0 {
+		mult = 2
+	}
+	secs := p.BackoffSeconds * math.Pow(mult, float64(retries))
+	if p.MaxBackoffSeconds > 0 && secs > p.MaxBackoffSeconds {
+		secs = p.MaxBackoffSeconds
+	}
+	return time.Duration(secs * float64(time.Second))
+}
+
+// retryGate holds retried tasks in the frontier until their backoff has elapsed.
+type retryGate struct {
+	now func() time.Time
+
+	mu        sync.Mutex
+	notBefore map[string]time.Time
+}
+
+func newRetryGate(now func() time.Time) *retryGate {
+	return &retryGate{now: now, notBefore: map[string]time.Time{}}
+}
+
+func (g *retryGate) hold(id string, until time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.notBefore[id] = until
+}
+
+// TryAcquire implements ResourceGate.
+func (g *retryGate) TryAcquire(task m.Task) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	at, ok := g.notBefore[task.ID]
+	if !ok {
+		return true
+	}
+	if g.now().Before(at) {
+		return false
+	}
+	delete(g.notBefore, task.ID)
+	return true
+}
+
+// Release implements ResourceGate.
+func (g *retryGate) Release(m.Task) {}
+
+// next returns the earliest pending backoff expiry.
+func (g *retryGate) next() (time.Time, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	var at time.Time
+	for _, t := range g.notBefore {
+		if at.IsZero() || t.Before(at) {
+			at = t
+		}
+	}
+	return at, !at.IsZero()
+}
@@ -0,0 +1,182 @@
+package exec
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/james/tasks-planner/internal/app/plan"
+	m "github.com/james/tasks-planner/internal/model"
+	"github.com/james/tasks-planner/internal/telemetry"
+)
+
+func TestBackoffGrowsExponentiallyAndCaps(t *testing.T) {
+	p := m.RetryPolicy{MaxAttempts: 5, BackoffSeconds: 1, MaxBackoffSeconds: 3}
+	want := []time.Duration{time.Second, 2 * time.Second, 3 * time.Second}
+	for i, w := range want {
+		if got := Backoff(p, i); got != w {
+			t.Fatalf("retry %d: expected %s, got %s", i, w, got)
+		}
+	}
+	if got := Backoff(m.RetryPolicy{MaxAttempts: 2}, 3); got != 0 {
+		t.Fatalf("expected no backoff, got %s", got)
+	}
+}
+
+func TestRetryableMatchesExitOrErrorCodes(t *testing.T) {
+	if !Retryable(m.RetryPolicy{}, 1, nil) {
+		t.Fatal("policy without codes should retry every failure")
+	}
+	p := m.RetryPolicy{RetryableExitCodes: []int{75}, RetryableErrorCodes: []string{"E_NET"}}
+	if !Retryable(p, 75, nil) || !Retryable(p, 1, map[string]bool{"E_NET": true}) {
+		t.Fatal("expected matching exit or error code to be retryable")
+	}
+	if Retryable(p, 1, map[string]bool{"E_SYNTAX": true}) {
+		t.Fatal("expected non-matching failure not to be retried")
+	}
+	coord := chainCoordinator([]string{"A"}, nil)
+	coord.Config.Policies.Retry = m.RetryPolicy{MaxAttempts: -1}
+	if err := ValidateRetryPolicies(coord); err == nil {
+		t.Fatal("expected negative max_attempts to be rejected")
+	}
+}
+
+func TestRuntimeRetriesFlakyTaskBeforeGatingDependents(t *testing.T) {
+	coord := chainCoordinator([]string{"A", "B"}, [][2]string{{"A", "B"}})
+	coord.Config.Policies.Retry = m.RetryPolicy{MaxAttempts: 3, BackoffSeconds: 0.01}
+	var mu sync.Mutex
+	attempts := map[string]int{}
+	var events []Event
+	rt := &Runtime{
+		Runner: func(ctx context.Context, task m.Task) error {
+			mu.Lock()
+			defer mu.Unlock()
+			attempts[task.ID]++
+			if task.ID == "A" && attempts["A"] < 3 {
+				return errors.New("flaky")
+			}
+			return nil
+		},
+		Notify: func(ev Event) { events = append(events, ev) },
+	}
+	if err := rt.Init(context.Background(), coord); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	if err := rt.Run(context.Background()); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if attempts["A"] != 3 || attempts["B"] != 1 {
+		t.Fatalf("unexpected attempts %v", attempts)
+	}
+	var retries []int
+	for _, ev := range events {
+		if ev.State == TaskRetrying {
+			retries = append(retries, ev.Attempt)
+		}
+	}
+	if len(retries) != 2 || retries[0] != 2 || retries[1] != 3 {
+		t.Fatalf("expected retry events for attempts 2 and 3, got %v", retries)
+	}
+}
+
+func TestRuntimeTaskOverrideLimitsRetriesToErrorCodes(t *testing.T) {
+	coord := chainCoordinator([]string{"A", "B"}, [][2]string{{"A", "B"}})
+	coord.Config.Policies.Retry = m.RetryPolicy{MaxAttempts: 5}
+	coord.Graph.Nodes[0].Retry = &m.RetryPolicy{MaxAttempts: 5, RetryableErrorCodes: []string{"E_NET"}}
+	var rt *Runtime
+	calls := 0
+	rt = &Runtime{Runner: func(ctx context.Context, task m.Task) error {
+		calls++
+		code := "E_NET"
+		if calls > 1 {
+			code = "E_SYNTAX"
+		}
+		rt.ObserveTelemetry(task, telemetry.Line{Status: telemetry.StatusError, Data: map[string]any{"error_code": code}})
+		return errors.New(code)
+	}}
+	if err := rt.Init(context.Background(), coord); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	if err := rt.Run(context.Background()); !errors.Is(err, ErrTasksFailed) {
+		t.Fatalf("expected ErrTasksFailed, got %v", err)
+	}
+	if calls != 2 {
+		t.Fatalf("expected one retry for E_NET then failure on E_SYNTAX, got %d calls", calls)
+	}
+	if st := rt.States(); st["A"] != TaskFailed || st["B"] != TaskBlocked {
+		t.Fatalf("unexpected states %v", st)
+	}
+}
+
+func TestRuntimeRetryPolicyGovernsCompensatedTask(t *testing.T) {
+	coord := chainCoordinator([]string{"A"}, nil)
+	coord.Graph.Nodes[0].Compensation.RollbackCmd = "undo A"
+	coord.Config.Policies.Retry = m.RetryPolicy{MaxAttempts: 3, BackoffSeconds: 0.01}
+	runs, rollbacks := 0, 0
+	var states []TaskState
+	rt := &Runtime{
+		Runner: func(ctx context.Context, task m.Task) error {
+			runs++
+			return errors.New("half applied")
+		},
+		Compensator: func(ctx context.Context, task m.Task) error {
+			rollbacks++
+			return nil
+		},
+		Notify: func(ev Event) { states = append(states, ev.State) },
+	}
+	if err := rt.Init(context.Background(), coord); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	if err := rt.Run(context.Background()); !errors.Is(err, ErrTasksFailed) {
+		t.Fatalf("expected ErrTasksFailed, got %v", err)
+	}
+	// max_attempts, not MaxCompensations, bounds the attempts; each one is rolled back once.
+	if runs != 3 || rollbacks != 3 {
+		t.Fatalf("expected 3 attempts each rolled back, got runs=%d rollbacks=%d", runs, rollbacks)
+	}
+	want := []TaskState{
+		TaskRunning, TaskRolledBack, TaskRetrying,
+		TaskRunning, TaskRolledBack, TaskRetrying,
+		TaskRunning, TaskRolledBack, TaskFailed,
+	}
+	if !reflect.DeepEqual(states, want) {
+		t.Fatalf("states = %v, want %v", states, want)
+	}
+}
+
+func TestRuntimeCompensatesPlannerDefaultPolicy(t *testing.T) {
+	// The planner emits max_attempts 1, which grants no retries: the compensation budget applies.
+	task := m.Task{ID: "A"}
+	task.Compensation.RollbackCmd = "undo A"
+	coord := plan.DefaultCoordinatorBuilder{}.Build([]m.Task{task}, nil)
+	runs, rollbacks := 0, 0
+	var states []TaskState
+	rt := &Runtime{
+		Runner: func(ctx context.Context, task m.Task) error {
+			runs++
+			if runs == 1 {
+				return errors.New("half applied")
+			}
+			return nil
+		},
+		Compensator: func(ctx context.Context, task m.Task) error {
+			rollbacks++
+			return nil
+		},
+		Notify: func(ev Event) { states = append(states, ev.State) },
+	}
+	if err := rt.Init(context.Background(), coord); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	if err := rt.Run(context.Background()); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	want := []TaskState{TaskRunning, TaskRolledBack, TaskReady, TaskRunning, TaskDone}
+	if runs != 2 || rollbacks != 1 || !reflect.DeepEqual(states, want) {
+		t.Fatalf("runs=%d rollbacks=%d states=%v, want 2 runs, 1 rollback, %v", runs, rollbacks, states, want)
+	}
+}
@@ -0,0 +1,630 @@
+package exec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	m "github.com/james/tasks-planner/internal/model"
+	"github.com/james/tasks-planner/internal/telemetry"
+)
+
+// ErrTasksFailed is returned when the loop drains with failed or blocked tasks.
+var ErrTasksFailed = errors.New("tasks did not complete")
+
+// TaskRunner executes a single task to completion. A nil error marks the task done.
+type TaskRunner func(ctx context.Context, task m.Task) error
+
+// Event describes a scheduler transition emitted by the runtime.
+type Event struct {
+	TaskID string
+	Task   m.Task
+	State  TaskState
+	Time   time.Time
+	Err    error
+	// Attempt is the 1-based dispatch attempt the event concerns; for TaskRetrying it is the attempt
+	// about to be made.
+	Attempt int
+	// WorkerID is the pool worker the task was admitted to (empty without Workers).
+	WorkerID string
+	// CheckpointID names the checkpoint that reflects this transition (empty without a StateDir).
+	CheckpointID string
+}
+
+// Recorder durably persists scheduler transitions. Unlike Notify, a Recorder error aborts the
+// run: a transition that cannot be recorded must not proceed unrecorded.
+type Recorder interface {
+	Record(ev Event) error
+}
+
+// PatchRecorder is implemented by recorders that also persist accepted hot patches. The patch is
+// recorded, stamped with the runtime clock's at, before it takes effect and is refused when
+// recording fails.
+type PatchRecorder interface {
+	RecordPatch(at time.Time, p m.Patch, res PatchResult, checkpointID string) error
+}
+
+// Runtime drives a Scheduler: it dispatches frontier tasks to the TaskRunner, blocks for the next
+// completion or failure, updates the frontier, and repeats until nothing is left to run.
+type Runtime struct {
+	Runner   TaskRunner
+	Profile  string
+	Notify   func(Event)
+	Recorder Recorder
+	Now      func() time.Time
+	// BreakerPath persists circuit breaker state across restarts (not persisted when empty).
+	BreakerPath string
+	// OnBreaker observes breakers opening and closing (optional).
+	OnBreaker func(BreakerState)
+	// NonConforming lists the tasks whose workers emitted invalid telemetry, flagged in Graph
+	// (optional; typically TelemetryMonitor.NonConforming).
+	NonConforming func() []string
+	// Compensator runs a task's Compensation.RollbackCmd. Without one, failures are never compensated.
+	Compensator TaskRunner
+	// MaxCompensations bounds how often a failed non-idempotent task whose retry policy allows no
+	// retries (max_attempts of 1 or less) is compensated and requeued; zero falls back to
+	// Policies.MaxCompensations and then to 1. Tasks with retries are requeued as their policy
+	// allows instead. A final failure is still compensated.
+	MaxCompensations int
+	// RollbackTimeout bounds each rollback command (DefaultRollbackTimeout when zero). Rollbacks
+	// run detached from the run's context so a cancelled run still undoes what it applied.
+	RollbackTimeout time.Duration
+	// StateDir keeps a checkpoint per coordinator hash after every transition so an interrupted
+	// run can Resume (not checkpointed when empty). Breaker state defaults to living there too.
+	StateDir string
+	// Workers, when set, gates dispatch on a free worker with the task's capabilities. Runner is
+	// expected to execute through it (typically WithAcceptance(Workers.Run, ...)).
+	Workers *WorkerPool
+	// Prioritizer orders the frontier; when nil a WeightedPrioritizer is built from
+	// Policies.Priority (and rebuilt when a patch changes the graph).
+	Prioritizer Prioritizer
+
+	mu       sync.Mutex
+	coord    m.Coordinator
+	sched    *Scheduler
+	locks    *LockManager
+	quotas   *QuotaManager
+	breakers *BreakerSet
+	fault    error
+	kick     chan struct{}
+	requeued map[string]int
+	retry    *retryGate
+	retries  map[string]int
+	codes    map[string]map[string]bool
+	store    *CheckpointStore
+	run      string
+	cpSeq    int
+	patches  []m.Patch
+}
+
+type taskResult struct {
+	id  string
+	err error
+}
+
+// Init builds the scheduler from the coordinator contract and attaches the resource managers.
+func (r *Runtime) Init(ctx context.Context, coord m.Coordinator) error {
+	var store *CheckpointStore
+	breakerPath := r.BreakerPath
+	if r.StateDir != "" {
+		h, err := CoordinatorHash(coord)
+		if err != nil {
+			return err
+		}
+		store = &CheckpointStore{Dir: r.StateDir, Hash: h}
+		if breakerPath == "" {
+			breakerPath = store.BreakerPath()
+		}
+	}
+	coord, err := ApplyProfile(coord, r.Profile)
+	if err != nil {
+		return err
+	}
+	if err := ValidateRetryPolicies(coord); err != nil {
+		return err
+	}
+	sched, err := NewScheduler(coord)
+	if err != nil {
+		return err
+	}
+	prio := r.Prioritizer
+	if prio == nil {
+		if prio, err = NewWeightedPrioritizer(coord); err != nil {
+			return err
+		}
+	}
+	sched.SetPrioritizer(prio, r.now)
+	quotas, err := NewQuotaManager(coord)
+	if err != nil {
+		return err
+	}
+	breakers, err := NewBreakerSet(coord.Config.Policies.CircuitBreakers, breakerPath)
+	if err != nil {
+		return err
+	}
+	breakers.Now = r.now
+	breakers.OnChange = r.OnBreaker
+	locks := NewLockManager(coord)
+	retry := newRetryGate(r.now)
+	sched.AddGate(retry)
+	sched.AddGate(breakers)
+	sched.AddGate(locks)
+	sched.AddGate(quotas)
+	if r.Workers != nil {
+		if err := r.Workers.Check(coord.Graph.Nodes); err != nil {
+			return err
+		}
+		sched.AddGate(r.Workers)
+	}
+	r.mu.Lock()
+	r.coord = coord
+	r.sched = sched
+	r.locks = locks
+	r.quotas = quotas
+	r.breakers = breakers
+	r.kick = make(chan struct{}, 1)
+	r.requeued = map[string]int{}
+	r.retry = retry
+	r.retries = map[string]int{}
+	r.codes = map[string]map[string]bool{}
+	r.store = store
+	r.cpSeq = 0
+	r.patches = nil
+	if store != nil {
+		r.run = fmt.Sprintf("%.12s-%s", store.Hash, strconv.FormatInt(r.now().UnixNano(), 36))
+	}
+	r.mu.Unlock()
+	return nil
+}
+
+// Run executes the rolling-frontier loop until every reachable task has finished or ctx is done.
+func (r *Runtime) Run(ctx context.Context) error {
+	if r.Runner == nil {
+		return errors.New("exec runtime: no task runner configured")
+	}
+	r.mu.Lock()
+	sched, kick := r.sched, r.kick
+	r.mu.Unlock()
+	if sched == nil {
+		return errors.New("exec runtime: not initialized")
+	}
+
+	results := make(chan taskResult)
+	inflight := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			r.drain(results, inflight)
+			return err
+		}
+		r.mu.Lock()
+		fault := r.fault
+		batch := sched.Next()
+		for _, task := range batch {
+			delete(r.codes, task.ID)
+		}
+		r.mu.Unlock()
+		if fault != nil {
+			r.drain(results, inflight)
+			return fault
+		}
+		for _, task := range batch {
+			if err := r.emit(task, TaskRunning, nil); err != nil {
+				r.drain(results, inflight)
+				return err
+			}
+			inflight++
+			go func(task m.Task) {
+				results <- taskResult{id: task.ID, err: r.Runner(ctx, task)}
+			}(task)
+		}
+		wake, stop := r.heldWake()
+		if inflight == 0 && wake == nil {
+			break
+		}
+		select {
+		case res := <-results:
+			stop()
+			inflight--
+			if err := r.handle(ctx, res); err != nil {
+				r.drain(results, inflight)
+				return err
+			}
+		case <-wake:
+		case <-kick:
+			stop()
+		case <-ctx.Done():
+			stop()
+			r.drain(results, inflight)
+			return ctx.Err()
+		}
+	}
+
+	r.mu.Lock()
+	unfinished := sched.Unfinished()
+	r.mu.Unlock()
+	if len(unfinished) > 0 {
+		return fmt.Errorf("%w: %s", ErrTasksFailed, strings.Join(unfinished, ", "))
+	}
+	return nil
+}
+
+// heldWake returns a channel that fires when the next open breaker closes or retry backoff
+// expires, or nil when no ready task is being held back.
+func (r *Runtime) heldWake() (<-chan time.Time, func()) {
+	r.mu.Lock()
+	breakers, retry, idle := r.breakers, r.retry, r.sched.Finished()
+	r.mu.Unlock()
+	if idle {
+		return nil, func() {}
+	}
+	at, ok := retry.next()
+	if breakers != nil {
+		if reopen, open := breakers.NextReopen(); open && (!ok || reopen.Before(at)) {
+			at, ok = reopen, true
+		}
+	}
+	if !ok {
+		return nil, func() {}
+	}
+	timer := time.NewTimer(at.Sub(r.now()))
+	return timer.C, func() { timer.Stop() }
+}
+
+func (r *Runtime) handle(ctx context.Context, res taskResult) error {
+	r.mu.Lock()
+	var err error
+	state := TaskDone
+	task, _ := r.sched.Task(res.id)
+	if res.err != nil && r.Compensator != nil && !task.Compensation.Idempotent && task.Compensation.RollbackCmd != "" {
+		retry := r.compensationRetryLocked(task, res.err)
+		r.mu.Unlock()
+		return r.compensate(ctx, task, res.err, retry)
+	}
+	if res.err != nil {
+		if delay, ok := r.retryDelayLocked(task, res.err); ok {
+			r.retries[res.id]++
+			err = r.sched.Requeue(res.id, nil)
+			r.retry.hold(res.id, r.now().Add(delay))
+			r.mu.Unlock()
+			if err != nil {
+				return err
+			}
+			return r.emit(task, TaskRetrying, res.err)
+		}
+		state = TaskFailed
+		err = r.sched.Fail(res.id)
+	} else {
+		err = r.sched.Complete(res.id)
+	}
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return r.emit(task, state, res.err)
+}
+
+// retryDelayLocked reports whether the failed task gets another attempt under its retry policy
+// and how long it must back off first. Callers hold r.mu.
+func (r *Runtime) retryDelayLocked(task m.Task, cause error) (time.Duration, bool) {
+	if errors.Is(cause, context.Canceled) {
+		return 0, false
+	}
+	p := RetryPolicyFor(r.coord, task)
+	done := r.retries[task.ID]
+	if done+1 >= p.MaxAttempts || !Retryable(p, exitCode(cause), r.codes[task.ID]) {
+		return 0, false
+	}
+	return Backoff(p, done), true
+}
+
+// DefaultRollbackTimeout bounds a rollback command when Runtime.RollbackTimeout is zero.
+const DefaultRollbackTimeout = 5 * time.Minute
+
+// compensationRetry is the decision, taken before any rollback runs, on whether a compensated
+// task goes back to the frontier.
+type compensationRetry struct {
+	ok bool
+	// policy marks a retry granted by the task's retry policy: it counts as an attempt and waits
+	// out delay. Otherwise it is one of MaxCompensations immediate requeues.
+	policy bool
+	delay  time.Duration
+}
+
+// compensationRetryLocked consults the task's retry policy, which governs when it allows more
+// than one attempt. A policy of at most one attempt, such as the planner's default, grants no
+// retries, so those tasks are requeued up to MaxCompensations times instead. Callers hold r.mu.
+func (r *Runtime) compensationRetryLocked(task m.Task, cause error) compensationRetry {
+	if RetryPolicyFor(r.coord, task).MaxAttempts > 1 {
+		delay, ok := r.retryDelayLocked(task, cause)
+		return compensationRetry{ok: ok, policy: true, delay: delay}
+	}
+	limit := r.MaxCompensations
+	if limit <= 0 {
+		limit = r.coord.Config.Policies.MaxCompensations
+	}
+	if limit <= 0 {
+		limit = 1
+	}
+	return compensationRetry{ok: !errors.Is(cause, context.Canceled) && r.requeued[task.ID] < limit}
+}
+
+// compensate undoes a failed non-idempotent task: rollbacks run for the task and the completed
+// tasks its failure affects (see Scheduler.Compensation) in reverse topological order, while the
+// task still holds its resources. If retry allows and every rollback succeeded, the task then
+// returns to the frontier with the undone tasks pending again; otherwise it fails for good. Every
+// step is emitted, so it lands in provenance.
+func (r *Runtime) compensate(ctx context.Context, task m.Task, cause error, retry compensationRetry) error {
+	r.mu.Lock()
+	plan := r.sched.Compensation(task.ID)
+	r.mu.Unlock()
+	timeout := r.RollbackTimeout
+	if timeout <= 0 {
+		timeout = DefaultRollbackTimeout
+	}
+	var rollbackErr error
+	for _, id := range plan {
+		r.mu.Lock()
+		t, _ := r.sched.Task(id)
+		r.mu.Unlock()
+		if t.Compensation.RollbackCmd == "" {
+			continue
+		}
+		rctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+		err := r.Compensator(rctx, t)
+		cancel()
+		if eerr := r.emit(t, TaskRolledBack, err); eerr != nil {
+			return eerr
+		}
+		if err != nil {
+			rollbackErr = fmt.Errorf("rollback %s: %w", id, err)
+			break
+		}
+	}
+	r.mu.Lock()
+	requeue := retry.ok && rollbackErr == nil
+	var err error
+	switch {
+	case requeue && retry.policy:
+		r.retries[task.ID]++
+		err = r.sched.Requeue(task.ID, plan)
+		r.retry.hold(task.ID, r.now().Add(retry.delay))
+	case requeue:
+		r.requeued[task.ID]++
+		err = r.sched.Requeue(task.ID, plan)
+	default:
+		err = r.sched.Fail(task.ID)
+	}
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	switch {
+	case requeue && retry.policy:
+		return r.emit(task, TaskRetrying, cause)
+	case requeue:
+		return r.emit(task, TaskReady, cause)
+	}
+	return r.emit(task, TaskFailed, errors.Join(cause, rollbackErr))
+}
+
+// drain waits for in-flight runners so no goroutine outlives Run.
+func (r *Runtime) drain(results <-chan taskResult, inflight int) {
+	for ; inflight > 0; inflight-- {
+		<-results
+	}
+}
+
+func (r *Runtime) emit(task m.Task, state TaskState, err error) error {
+	r.mu.Lock()
+	cpID := r.nextCheckpointLocked()
+	attempt := r.retries[task.ID] + 1
+	r.mu.Unlock()
+	ev := Event{TaskID: task.ID, Task: task, State: state, Time: r.now(), Err: err, Attempt: attempt, CheckpointID: cpID}
+	if r.Workers != nil {
+		ev.WorkerID = r.Workers.Assigned(task.ID)
+	}
+	if r.Notify != nil {
+		r.Notify(ev)
+	}
+	if r.Recorder != nil {
+		if rerr := r.Recorder.Record(ev); rerr != nil {
+			return fmt.Errorf("record %s %s: %w", task.ID, state, rerr)
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.saveCheckpointLocked()
+}
+
+// nextCheckpointLocked reserves the ID of the checkpoint that will reflect the transition being
+// recorded. Callers hold r.mu.
+func (r *Runtime) nextCheckpointLocked() string {
+	if r.store == nil {
+		return ""
+	}
+	r.cpSeq++
+	return checkpointID(r.run, r.cpSeq)
+}
+
+// saveCheckpointLocked persists the current state under the latest reserved ID. Callers hold r.mu.
+func (r *Runtime) saveCheckpointLocked() error {
+	if r.store == nil {
+		return nil
+	}
+	cp := Checkpoint{
+		CoordinatorHash: r.store.Hash,
+		Profile:         r.Profile,
+		Run:             r.run,
+		Seq:             r.cpSeq,
+		Time:            formatTime(r.now()),
+		States:          r.sched.Snapshot(),
+		Locks:           r.locks.Held(),
+		Patches:         r.patches,
+	}
+	if len(r.retries) > 0 {
+		cp.Retries = make(map[string]int, len(r.retries))
+		for id, n := range r.retries {
+			cp.Retries[id] = n
+		}
+	}
+	if len(r.requeued) > 0 {
+		cp.Requeued = make(map[string]int, len(r.requeued))
+		for id, n := range r.requeued {
+			cp.Requeued[id] = n
+		}
+	}
+	return r.store.Save(cp)
+}
+
+func (r *Runtime) now() time.Time {
+	if r.Now != nil {
+		return r.Now()
+	}
+	return time.Now()
+}
+
+// SetProgress forwards a worker's reported progress to the scheduler.
+func (r *Runtime) SetProgress(taskID string, pct float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.sched != nil {
+		r.sched.SetProgress(taskID, pct)
+	}
+}
+
+// Progress returns the last reported percentage for taskID.
+func (r *Runtime) Progress(taskID string) float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.sched == nil {
+		return 0
+	}
+	return r.sched.Progress(taskID)
+}
+
+// ApplyPatch applies a hot patch to the running plan. The patch must pass ApplyPatch against the
+// current contract and Scheduler.Extend against live task states, and is recorded (when the
+// Recorder supports it) before it takes effect. New frontier tasks are dispatched promptly.
+func (r *Runtime) ApplyPatch(p m.Patch) (PatchResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.sched == nil {
+		return PatchResult{PatchID: p.ID}, errors.New("exec runtime: not initialized")
+	}
+	coord, res, err := ApplyPatch(r.coord, p)
+	if err != nil {
+		return res, err
+	}
+	tasks := patchTasks(p)
+	if err := r.sched.CheckExtend(tasks, res.Edges); err != nil {
+		return res, fmt.Errorf("%w: %s: %v", ErrPatchRejected, p.ID, err)
+	}
+	if r.Workers != nil {
+		if err := r.Workers.Check(tasks); err != nil {
+			return res, fmt.Errorf("%w: %s: %v", ErrPatchRejected, p.ID, err)
+		}
+	}
+	cpID := r.nextCheckpointLocked()
+	if pr, ok := r.Recorder.(PatchRecorder); ok {
+		if err := pr.RecordPatch(r.now(), p, res, cpID); err != nil {
+			return res, fmt.Errorf("record patch %s: %w", p.ID, err)
+		}
+	}
+	if err := r.extendLocked(coord, tasks, res.Edges, p); err != nil {
+		return res, err
+	}
+	if err := r.saveCheckpointLocked(); err != nil {
+		return res, err
+	}
+	select {
+	case r.kick <- struct{}{}:
+	default:
+	}
+	return res, nil
+}
+
+// extendLocked makes an accepted patch take effect. Callers hold r.mu.
+func (r *Runtime) extendLocked(coord m.Coordinator, tasks []m.Task, edges []m.Edge, p m.Patch) error {
+	if err := r.sched.Extend(tasks, edges); err != nil {
+		return err
+	}
+	if r.Prioritizer == nil {
+		prio, err := NewWeightedPrioritizer(coord)
+		if err != nil {
+			return err
+		}
+		r.sched.SetPrioritizer(prio, r.now)
+	}
+	r.coord = coord
+	r.locks.Reorder(coord)
+	r.quotas.SetCatalog(coord.Config.Resources.Catalog)
+	r.patches = append(r.patches, p)
+	return nil
+}
+
+func patchTasks(p m.Patch) []m.Task {
+	var tasks []m.Task
+	for _, op := range p.Ops {
+		if op.Op == m.PatchAddTask && op.Task != nil {
+			tasks = append(tasks, *op.Task)
+		}
+	}
+	return tasks
+}
+
+// Coordinator returns the contract currently being executed, including applied patches.
+func (r *Runtime) Coordinator() m.Coordinator {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.coord
+}
+
+// ObserveTelemetry feeds a worker telemetry line to the circuit breakers and remembers its error
+// code for retry decisions. A failure to persist breaker state aborts the run at the next
+// scheduling step.
+func (r *Runtime) ObserveTelemetry(task m.Task, line telemetry.Line) {
+	r.mu.Lock()
+	breakers := r.breakers
+	if code := line.ErrorCode(); code != "" && r.codes != nil {
+		if r.codes[task.ID] == nil {
+			r.codes[task.ID] = map[string]bool{}
+		}
+		r.codes[task.ID][code] = true
+	}
+	r.mu.Unlock()
+	if breakers == nil {
+		return
+	}
+	if err := breakers.Observe(task, line); err != nil {
+		r.mu.Lock()
+		if r.fault == nil {
+			r.fault = err
+		}
+		r.mu.Unlock()
+	}
+}
+
+// Breakers returns the circuit breaker states, or nil before Init.
+func (r *Runtime) Breakers() []BreakerState {
+	r.mu.Lock()
+	breakers := r.breakers
+	r.mu.Unlock()
+	if breakers == nil {
+		return nil
+	}
+	return breakers.States()
+}
+
+// States returns a snapshot of task states, or nil before Init.
+func (r *Runtime) States() map[string]TaskState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.sched == nil {
+		return nil
+	}
+	return r.sched.Snapshot()
+}
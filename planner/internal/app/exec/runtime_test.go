@@ -0,0 +1,270 @@
+package exec
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/james/tasks-planner/internal/app/plan"
+	m "github.com/james/tasks-planner/internal/model"
+	"github.com/james/tasks-planner/internal/telemetry"
+)
+
+func TestRuntimeRunsInDependencyOrder(t *testing.T) {
+	coord := chainCoordinator([]string{"A", "B", "C"}, [][2]string{{"A", "B"}, {"B", "C"}})
+	var mu sync.Mutex
+	var order []string
+	rt := &Runtime{Runner: func(ctx context.Context, task m.Task) error {
+		mu.Lock()
+		order = append(order, task.ID)
+		mu.Unlock()
+		return nil
+	}}
+	if err := rt.Init(context.Background(), coord); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	if err := rt.Run(context.Background()); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if len(order) != 3 || order[0] != "A" || order[1] != "B" || order[2] != "C" {
+		t.Fatalf("unexpected order %v", order)
+	}
+}
+
+func TestRuntimeReportsFailures(t *testing.T) {
+	coord := chainCoordinator([]string{"A", "B"}, [][2]string{{"A", "B"}})
+	var events []Event
+	rt := &Runtime{
+		Runner: func(ctx context.Context, task m.Task) error { return errors.New("boom") },
+		Notify: func(ev Event) { events = append(events, ev) },
+	}
+	if err := rt.Init(context.Background(), coord); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	err := rt.Run(context.Background())
+	if !errors.Is(err, ErrTasksFailed) {
+		t.Fatalf("expected ErrTasksFailed, got %v", err)
+	}
+	states := rt.States()
+	if states["A"] != TaskFailed || states["B"] != TaskBlocked {
+		t.Fatalf("unexpected states %v", states)
+	}
+	if len(events) != 2 || events[1].State != TaskFailed || events[1].Err == nil {
+		t.Fatalf("unexpected events %+v", events)
+	}
+}
+
+func TestRuntimeStopsOnCancel(t *testing.T) {
+	coord := chainCoordinator([]string{"A"}, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	rt := &Runtime{Runner: func(ctx context.Context, task m.Task) error {
+		cancel()
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+	if err := rt.Init(ctx, coord); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	if err := rt.Run(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestRuntimeWaitsForBreakerCooldown(t *testing.T) {
+	coord := chainCoordinator([]string{"A", "B"}, [][2]string{{"A", "B"}})
+	coord.Config.Policies.CircuitBreakers = []m.CircuitBreaker{{
+		Name: "flaky", ErrorCode: "E_FLAKY", WindowSeconds: 1, Threshold: 1, Action: m.BreakerActionPause,
+	}}
+	var opened time.Time
+	var rt *Runtime
+	rt = &Runtime{
+		Runner: func(ctx context.Context, task m.Task) error {
+			if task.ID == "A" {
+				rt.ObserveTelemetry(task, telemetry.Line{Status: telemetry.StatusError, Data: map[string]any{"error_code": "E_FLAKY"}})
+				return nil
+			}
+			if time.Since(opened) < time.Second {
+				t.Errorf("B dispatched %v after the breaker opened", time.Since(opened))
+			}
+			return nil
+		},
+		OnBreaker: func(st BreakerState) {
+			if st.Open {
+				opened = *st.OpenedAt
+			}
+		},
+	}
+	if err := rt.Init(context.Background(), coord); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	if err := rt.Run(context.Background()); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if rt.Breakers()[0].Trips != 1 {
+		t.Fatalf("expected one trip, got %+v", rt.Breakers())
+	}
+}
+
+func TestRuntimeAppliesPatchMidRun(t *testing.T) {
+	coord := chainCoordinator([]string{"A", "B"}, [][2]string{{"A", "B"}})
+	var mu sync.Mutex
+	var order []string
+	var rt *Runtime
+	rt = &Runtime{Runner: func(ctx context.Context, task m.Task) error {
+		mu.Lock()
+		order = append(order, task.ID)
+		mu.Unlock()
+		if task.ID != "A" {
+			return nil
+		}
+		_, err := rt.ApplyPatch(m.Patch{ID: "deps", Ops: []m.PatchOp{
+			{Op: m.PatchAddTask, Task: &m.Task{ID: "DEPS"}},
+			{Op: m.PatchAddEdge, Edge: &m.Edge{From: "DEPS", To: "B", Type: "infrastructure", IsHard: true, Confidence: 1}},
+		}})
+		return err
+	}}
+	if err := rt.Init(context.Background(), coord); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	if err := rt.Run(context.Background()); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if len(order) != 3 || order[2] != "B" {
+		t.Fatalf("expected B to run after the patched-in DEPS, got %v", order)
+	}
+	if got := len(rt.Coordinator().Graph.Nodes); got != 3 {
+		t.Fatalf("expected patched coordinator with 3 nodes, got %d", got)
+	}
+}
+
+func TestRuntimeCompensatesNonIdempotentFailure(t *testing.T) {
+	coord := chainCoordinator([]string{"A", "B"}, [][2]string{{"A", "B"}})
+	coord.Graph.Nodes[0].Compensation.RollbackCmd = "undo A"
+	var mu sync.Mutex
+	attempts := map[string]int{}
+	var rollbacks []string
+	var states []TaskState
+	rt := &Runtime{
+		Runner: func(ctx context.Context, task m.Task) error {
+			mu.Lock()
+			defer mu.Unlock()
+			attempts[task.ID]++
+			if task.ID == "A" && attempts["A"] == 1 {
+				return errors.New("half applied")
+			}
+			return nil
+		},
+		Compensator: func(ctx context.Context, task m.Task) error {
+			rollbacks = append(rollbacks, task.ID)
+			return nil
+		},
+		Notify: func(ev Event) {
+			if ev.TaskID == "A" {
+				states = append(states, ev.State)
+			}
+		},
+	}
+	if err := rt.Init(context.Background(), coord); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	if err := rt.Run(context.Background()); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if attempts["A"] != 2 || attempts["B"] != 1 || len(rollbacks) != 1 {
+		t.Fatalf("expected A retried once after one rollback, got attempts %v rollbacks %v", attempts, rollbacks)
+	}
+	want := []TaskState{TaskRunning, TaskRolledBack, TaskReady, TaskRunning, TaskDone}
+	if len(states) != len(want) {
+		t.Fatalf("expected %v, got %v", want, states)
+	}
+	for i := range want {
+		if states[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, states)
+		}
+	}
+}
+
+func TestRuntimeFailsWhenRollbackFails(t *testing.T) {
+	coord := chainCoordinator([]string{"A"}, nil)
+	coord.Graph.Nodes[0].Compensation.RollbackCmd = "undo A"
+	runs := 0
+	rt := &Runtime{
+		Runner: func(ctx context.Context, task m.Task) error {
+			runs++
+			return errors.New("boom")
+		},
+		Compensator: func(ctx context.Context, task m.Task) error { return errors.New("rollback broke") },
+	}
+	if err := rt.Init(context.Background(), coord); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	if err := rt.Run(context.Background()); !errors.Is(err, ErrTasksFailed) {
+		t.Fatalf("expected ErrTasksFailed, got %v", err)
+	}
+	if runs != 1 || rt.States()["A"] != TaskFailed {
+		t.Fatalf("expected a single failed attempt, got runs=%d states=%v", runs, rt.States())
+	}
+}
+
+func TestRuntimeMaxCompensationsFromPolicy(t *testing.T) {
+	coord := chainCoordinator([]string{"A"}, nil)
+	coord.Graph.Nodes[0].Compensation.RollbackCmd = "undo A"
+	coord.Config.Policies.MaxCompensations = 2
+	runs, rollbacks := 0, 0
+	rt := &Runtime{
+		Runner: func(ctx context.Context, task m.Task) error {
+			runs++
+			return errors.New("boom")
+		},
+		Compensator: func(ctx context.Context, task m.Task) error {
+			rollbacks++
+			return nil
+		},
+	}
+	if err := rt.Init(context.Background(), coord); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	if err := rt.Run(context.Background()); !errors.Is(err, ErrTasksFailed) {
+		t.Fatalf("expected ErrTasksFailed, got %v", err)
+	}
+	if runs != 3 || rollbacks != 3 {
+		t.Fatalf("expected 3 runs each rolled back, got runs=%d rollbacks=%d", runs, rollbacks)
+	}
+}
+
+func TestRuntimeRequeuesPlannerTaskUntilCompensationsRunOut(t *testing.T) {
+	task := m.Task{ID: "A"}
+	task.Compensation.RollbackCmd = "undo A"
+	coord := plan.DefaultCoordinatorBuilder{}.Build([]m.Task{task}, nil)
+	coord.Config.Policies.MaxCompensations = 2
+	runs, rollbacks := 0, 0
+	var states []TaskState
+	rt := &Runtime{
+		Runner: func(ctx context.Context, task m.Task) error {
+			runs++
+			return errors.New("half applied")
+		},
+		Compensator: func(ctx context.Context, task m.Task) error {
+			rollbacks++
+			return nil
+		},
+		Notify: func(ev Event) { states = append(states, ev.State) },
+	}
+	if err := rt.Init(context.Background(), coord); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	if err := rt.Run(context.Background()); !errors.Is(err, ErrTasksFailed) {
+		t.Fatalf("expected ErrTasksFailed, got %v", err)
+	}
+	want := []TaskState{
+		TaskRunning, TaskRolledBack, TaskReady,
+		TaskRunning, TaskRolledBack, TaskReady,
+		TaskRunning, TaskRolledBack, TaskFailed,
+	}
+	if runs != 3 || rollbacks != 3 || !reflect.DeepEqual(states, want) {
+		t.Fatalf("runs=%d rollbacks=%d states=%v, want 3 runs each rolled back, %v", runs, rollbacks, states, want)
+	}
+}
@@ -0,0 +1,625 @@
+package exec
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	m "github.com/james/tasks-planner/internal/model"
+)
+
+// TaskState enumerates the lifecycle states tracked by the scheduler.
+type TaskState string
+
+const (
+	TaskPending TaskState = "pending" // waiting on hard predecessors
+	TaskReady   TaskState = "ready"   // eligible and sitting in the frontier
+	TaskRunning TaskState = "running"
+	TaskDone    TaskState = "done"
+	TaskFailed  TaskState = "failed"
+	TaskBlocked TaskState = "blocked" // a hard predecessor failed
+
+	// TaskRolledBack is never a scheduler state; the runtime emits it when a task's compensation ran.
+	TaskRolledBack TaskState = "rolled_back"
+	// TaskRetrying is never a scheduler state; the runtime emits it when a failed task is requeued
+	// under its retry policy.
+	TaskRetrying TaskState = "retrying"
+)
+
+// ErrCycle is returned when the coordinator graph cannot be ordered.
+var ErrCycle = errors.New("coordinator graph contains a cycle")
+
+// ResourceGate admits frontier tasks against runtime resources. TryAcquire must be all-or-nothing:
+// when it returns false the task holds nothing from this gate.
+type ResourceGate interface {
+	TryAcquire(task m.Task) bool
+	Release(task m.Task)
+}
+
+// Prioritizer scores ready tasks; waited is how long the task has been in the frontier.
+type Prioritizer interface {
+	Priority(task m.Task, waited time.Duration) float64
+}
+
+// Scheduler is the rolling-frontier state machine. It tracks which tasks have all hard
+// predecessors complete (the frontier), hands them out up to the concurrency limit, and
+// reacts to completion and failure events. It performs no I/O and is not safe for
+// concurrent use; callers serialize access.
+type Scheduler struct {
+	tasks    map[string]m.Task
+	ids      []string
+	succs    map[string][]string
+	waiting  map[string]int
+	state    map[string]TaskState
+	frontier []string
+	running  int
+	limit    int
+	gates    []ResourceGate
+	progress map[string]float64
+	prio     Prioritizer
+	now      func() time.Time
+	since    map[string]time.Time
+}
+
+// NewScheduler builds a scheduler from the coordinator contract. Only hard, non-resource edges
+// gate readiness; resource edges are traceability records and are enforced by resource managers.
+func NewScheduler(coord m.Coordinator) (*Scheduler, error) {
+	s := &Scheduler{
+		tasks:    make(map[string]m.Task, len(coord.Graph.Nodes)),
+		succs:    map[string][]string{},
+		waiting:  map[string]int{},
+		state:    map[string]TaskState{},
+		limit:    coord.Config.Policies.ConcurrencyMax,
+		progress: map[string]float64{},
+	}
+	for _, t := range coord.Graph.Nodes {
+		if t.ID == "" {
+			return nil, errors.New("scheduler: task with empty id")
+		}
+		if _, dup := s.tasks[t.ID]; dup {
+			return nil, fmt.Errorf("scheduler: duplicate task id %s", t.ID)
+		}
+		s.tasks[t.ID] = t
+		s.ids = append(s.ids, t.ID)
+		s.state[t.ID] = TaskPending
+	}
+	sort.Strings(s.ids)
+
+	seen := map[[2]string]bool{}
+	for _, e := range coord.Graph.Edges {
+		if !isPrecedence(e) {
+			continue
+		}
+		if _, ok := s.tasks[e.From]; !ok {
+			return nil, fmt.Errorf("scheduler: edge %s->%s references unknown task %s", e.From, e.To, e.From)
+		}
+		if _, ok := s.tasks[e.To]; !ok {
+			return nil, fmt.Errorf("scheduler: edge %s->%s references unknown task %s", e.From, e.To, e.To)
+		}
+		key := [2]string{e.From, e.To}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		s.succs[e.From] = append(s.succs[e.From], e.To)
+		s.waiting[e.To]++
+	}
+	for id := range s.succs {
+		sort.Strings(s.succs[id])
+	}
+	if err := s.checkAcyclic(); err != nil {
+		return nil, err
+	}
+	for _, id := range s.ids {
+		if s.waiting[id] == 0 {
+			s.state[id] = TaskReady
+			s.frontier = append(s.frontier, id)
+		}
+	}
+	return s, nil
+}
+
+func isPrecedence(e m.Edge) bool {
+	return e.IsHard && e.Type != "resource"
+}
+
+func (s *Scheduler) checkAcyclic() error {
+	indeg := make(map[string]int, len(s.waiting))
+	for id, n := range s.waiting {
+		indeg[id] = n
+	}
+	queue := []string{}
+	for _, id := range s.ids {
+		if indeg[id] == 0 {
+			queue = append(queue, id)
+		}
+	}
+	visited := 0
+	for len(queue) > 0 {
+		u := queue[0]
+		queue = queue[1:]
+		visited++
+		for _, v := range s.succs[u] {
+			indeg[v]--
+			if indeg[v] == 0 {
+				queue = append(queue, v)
+			}
+		}
+	}
+	if visited != len(s.ids) {
+		return ErrCycle
+	}
+	return nil
+}
+
+// AddGate registers a resource gate consulted before every dispatch.
+func (s *Scheduler) AddGate(g ResourceGate) {
+	s.gates = append(s.gates, g)
+}
+
+// SetPrioritizer makes Next consider the frontier in descending priority (ties keep readiness
+// order). now times how long each task has been ready. A nil p restores readiness order.
+func (s *Scheduler) SetPrioritizer(p Prioritizer, now func() time.Time) {
+	s.prio = p
+	s.now = now
+	if s.since == nil {
+		s.since = map[string]time.Time{}
+	}
+}
+
+// Next moves as many frontier tasks to running as the concurrency limit and resource gates allow
+// and returns them in dispatch order. Tasks a gate refuses stay in the frontier in their original
+// position. A limit of zero or less means unlimited.
+func (s *Scheduler) Next() []m.Task {
+	if s.prio != nil {
+		s.prioritize()
+	}
+	var out []m.Task
+	var deferred []string
+	for len(s.frontier) > 0 && (s.limit <= 0 || s.running < s.limit) {
+		id := s.frontier[0]
+		s.frontier = s.frontier[1:]
+		if !s.admit(s.tasks[id]) {
+			deferred = append(deferred, id)
+			continue
+		}
+		s.state[id] = TaskRunning
+		s.running++
+		if s.since != nil {
+			delete(s.since, id)
+		}
+		out = append(out, s.tasks[id])
+	}
+	s.frontier = append(deferred, s.frontier...)
+	return out
+}
+
+// prioritize stably sorts the frontier by descending priority, starting the aging clock of tasks
+// seen for the first time.
+func (s *Scheduler) prioritize() {
+	now := s.now()
+	score := make(map[string]float64, len(s.frontier))
+	for _, id := range s.frontier {
+		at, ok := s.since[id]
+		if !ok {
+			at = now
+			s.since[id] = at
+		}
+		score[id] = s.prio.Priority(s.tasks[id], now.Sub(at))
+	}
+	sort.SliceStable(s.frontier, func(i, j int) bool { return score[s.frontier[i]] > score[s.frontier[j]] })
+}
+
+// admit acquires every gate for task or, if any refuses, releases the ones already taken.
+func (s *Scheduler) admit(task m.Task) bool {
+	for i, g := range s.gates {
+		if !g.TryAcquire(task) {
+			for j := i - 1; j >= 0; j-- {
+				s.gates[j].Release(task)
+			}
+			return false
+		}
+	}
+	return true
+}
+
+// Complete marks a running task done and promotes successors whose hard predecessors are all done.
+func (s *Scheduler) Complete(id string) error {
+	if err := s.finish(id); err != nil {
+		return err
+	}
+	s.state[id] = TaskDone
+	s.progress[id] = 100
+	for _, v := range s.succs[id] {
+		s.waiting[v]--
+		if s.waiting[v] == 0 && s.state[v] == TaskPending {
+			s.state[v] = TaskReady
+			s.frontier = append(s.frontier, v)
+		}
+	}
+	return nil
+}
+
+// Fail marks a running task failed and blocks every task that transitively depends on it.
+func (s *Scheduler) Fail(id string) error {
+	if err := s.finish(id); err != nil {
+		return err
+	}
+	s.state[id] = TaskFailed
+	s.block(s.succs[id])
+	return nil
+}
+
+// block marks every pending task reachable from ids (inclusive) as blocked.
+func (s *Scheduler) block(ids []string) {
+	stack := append([]string(nil), ids...)
+	for len(stack) > 0 {
+		v := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if s.state[v] != TaskPending {
+			continue
+		}
+		s.state[v] = TaskBlocked
+		stack = append(stack, s.succs[v]...)
+	}
+}
+
+// Compensation returns the tasks whose effects must be undone when id fails, in reverse
+// topological order (dependents before the tasks they depend on): id itself, every completed task
+// that writes one of id's write resources or produces or consumes an interface id produces, and
+// every completed task that depends on one of those.
+func (s *Scheduler) Compensation(id string) []string {
+	failed := s.tasks[id]
+	writes := writeResources(failed)
+	produced := map[string]bool{}
+	for _, ip := range failed.InterfacesProduced {
+		produced[ip.Name] = true
+	}
+	affected := map[string]bool{id: true}
+	var stack []string
+	for _, u := range s.ids {
+		if u == id || s.state[u] != TaskDone {
+			continue
+		}
+		if sharesWrites(s.tasks[u], writes) || sharesInterface(s.tasks[u], produced) {
+			affected[u] = true
+			stack = append(stack, u)
+		}
+	}
+	for len(stack) > 0 {
+		u := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, v := range s.succs[u] {
+			if !affected[v] && s.state[v] == TaskDone {
+				affected[v] = true
+				stack = append(stack, v)
+			}
+		}
+	}
+	order := s.topoOrder()
+	out := make([]string, 0, len(affected))
+	for k := len(order) - 1; k >= 0; k-- {
+		if affected[order[k]] {
+			out = append(out, order[k])
+		}
+	}
+	return out
+}
+
+// topoOrder returns every task in Kahn order, smallest ID first among ready tasks.
+func (s *Scheduler) topoOrder() []string {
+	indeg := map[string]int{}
+	for _, vs := range s.succs {
+		for _, v := range vs {
+			indeg[v]++
+		}
+	}
+	var ready, out []string
+	for _, id := range s.ids {
+		if indeg[id] == 0 {
+			ready = append(ready, id)
+		}
+	}
+	for len(ready) > 0 {
+		sort.Strings(ready)
+		u := ready[0]
+		ready = ready[1:]
+		out = append(out, u)
+		for _, v := range s.succs[u] {
+			if indeg[v]--; indeg[v] == 0 {
+				ready = append(ready, v)
+			}
+		}
+	}
+	return out
+}
+
+// writeResources returns the resources task modifies: exclusive ones and limited needs with
+// write access.
+func writeResources(task m.Task) map[string]bool {
+	out := map[string]bool{}
+	for _, name := range task.Resources.Exclusive {
+		out[name] = true
+	}
+	for _, need := range task.Resources.Limited {
+		if need.Access == m.AccessWrite {
+			out[need.Name] = true
+		}
+	}
+	return out
+}
+
+func sharesWrites(task m.Task, writes map[string]bool) bool {
+	for name := range writeResources(task) {
+		if writes[name] {
+			return true
+		}
+	}
+	return false
+}
+
+func sharesInterface(task m.Task, names map[string]bool) bool {
+	for _, ip := range task.InterfacesProduced {
+		if names[ip.Name] {
+			return true
+		}
+	}
+	for _, ic := range task.InterfacesConsumed {
+		if names[ic.Name] {
+			return true
+		}
+	}
+	return false
+}
+
+// Requeue returns a running task whose failed attempt was compensated to the head of the frontier.
+// Completed tasks listed in undone had their effects rolled back and go back to pending; tasks
+// that now wait on an undone predecessor leave the frontier until it completes again.
+func (s *Scheduler) Requeue(id string, undone []string) error {
+	if err := s.finish(id); err != nil {
+		return err
+	}
+	s.state[id] = TaskReady
+	s.progress[id] = 0
+	s.frontier = append([]string{id}, s.frontier...)
+	for _, u := range undone {
+		if u != id && s.state[u] == TaskDone {
+			s.state[u] = TaskPending
+			s.progress[u] = 0
+		}
+	}
+	if len(undone) == 0 {
+		return nil
+	}
+	for _, u := range s.ids {
+		if st := s.state[u]; st == TaskPending || st == TaskReady {
+			s.waiting[u] = 0
+		}
+	}
+	for u, vs := range s.succs {
+		if s.state[u] == TaskDone {
+			continue
+		}
+		for _, v := range vs {
+			if st := s.state[v]; st == TaskPending || st == TaskReady {
+				s.waiting[v]++
+			}
+		}
+	}
+	frontier := s.frontier[:0]
+	for _, u := range s.frontier {
+		if s.waiting[u] > 0 {
+			s.state[u] = TaskPending
+			if s.since != nil {
+				delete(s.since, u)
+			}
+			continue
+		}
+		frontier = append(frontier, u)
+	}
+	s.frontier = frontier
+	for _, u := range s.ids {
+		if s.state[u] == TaskPending && s.waiting[u] == 0 {
+			s.state[u] = TaskReady
+			s.frontier = append(s.frontier, u)
+		}
+	}
+	return nil
+}
+
+func (s *Scheduler) finish(id string) error {
+	st, ok := s.state[id]
+	if !ok {
+		return fmt.Errorf("scheduler: unknown task %s", id)
+	}
+	if st != TaskRunning {
+		return fmt.Errorf("scheduler: task %s is %s, not running", id, st)
+	}
+	s.running--
+	for i := len(s.gates) - 1; i >= 0; i-- {
+		s.gates[i].Release(s.tasks[id])
+	}
+	return nil
+}
+
+// Finished reports whether nothing is running and nothing remains dispatchable.
+func (s *Scheduler) Finished() bool {
+	return s.running == 0 && len(s.frontier) == 0
+}
+
+// Task returns the task definition for id.
+func (s *Scheduler) Task(id string) (m.Task, bool) {
+	t, ok := s.tasks[id]
+	return t, ok
+}
+
+// SetProgress records the latest reported completion percentage for a running task.
+func (s *Scheduler) SetProgress(id string, pct float64) {
+	if s.state[id] == TaskRunning {
+		s.progress[id] = pct
+	}
+}
+
+// Progress returns the last reported percentage for id (0 if none).
+func (s *Scheduler) Progress(id string) float64 {
+	return s.progress[id]
+}
+
+// State returns the current state of a task.
+func (s *Scheduler) State(id string) (TaskState, bool) {
+	st, ok := s.state[id]
+	return st, ok
+}
+
+// Snapshot returns a copy of every task's state.
+func (s *Scheduler) Snapshot() map[string]TaskState {
+	out := make(map[string]TaskState, len(s.state))
+	for id, st := range s.state {
+		out[id] = st
+	}
+	return out
+}
+
+// Unfinished returns the sorted IDs of tasks that did not reach TaskDone.
+func (s *Scheduler) Unfinished() []string {
+	var out []string
+	for _, id := range s.ids {
+		if s.state[id] != TaskDone {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// CheckExtend validates the tasks and edges of a hot patch against the scheduler without changing
+// it: new task IDs must be unique, edge endpoints must exist, and a new precedence edge may not
+// target a task that has already started.
+func (s *Scheduler) CheckExtend(tasks []m.Task, edges []m.Edge) error {
+	added := map[string]bool{}
+	for _, t := range tasks {
+		if t.ID == "" {
+			return errors.New("scheduler: task with empty id")
+		}
+		if _, dup := s.tasks[t.ID]; dup || added[t.ID] {
+			return fmt.Errorf("scheduler: duplicate task id %s", t.ID)
+		}
+		added[t.ID] = true
+	}
+	for _, e := range edges {
+		if !isPrecedence(e) {
+			continue
+		}
+		for _, id := range []string{e.From, e.To} {
+			if _, ok := s.tasks[id]; !ok && !added[id] {
+				return fmt.Errorf("scheduler: edge %s->%s references unknown task %s", e.From, e.To, id)
+			}
+		}
+		if added[e.To] {
+			continue
+		}
+		switch st := s.state[e.To]; st {
+		case TaskRunning, TaskDone, TaskFailed:
+			return fmt.Errorf("scheduler: edge %s->%s targets task %s that is already %s", e.From, e.To, e.To, st)
+		}
+	}
+	return nil
+}
+
+// Extend adds the tasks and edges of an accepted hot patch after CheckExtend passes. New tasks join
+// the frontier unless they gain an unfinished predecessor; a ready task that does returns to
+// pending, and one whose new predecessor already failed is blocked. The caller is responsible for
+// keeping the graph acyclic.
+func (s *Scheduler) Extend(tasks []m.Task, edges []m.Edge) error {
+	if err := s.CheckExtend(tasks, edges); err != nil {
+		return err
+	}
+	for _, t := range tasks {
+		s.addTask(t)
+	}
+	for _, e := range edges {
+		if isPrecedence(e) {
+			s.addEdge(e.From, e.To)
+		}
+	}
+	return nil
+}
+
+func (s *Scheduler) addTask(task m.Task) {
+	s.tasks[task.ID] = task
+	i := sort.SearchStrings(s.ids, task.ID)
+	s.ids = append(s.ids, "")
+	copy(s.ids[i+1:], s.ids[i:])
+	s.ids[i] = task.ID
+	s.state[task.ID] = TaskReady
+	s.frontier = append(s.frontier, task.ID)
+}
+
+func (s *Scheduler) addEdge(from, to string) {
+	for _, v := range s.succs[from] {
+		if v == to {
+			return
+		}
+	}
+	s.succs[from] = append(s.succs[from], to)
+	sort.Strings(s.succs[from])
+	if s.state[from] == TaskDone {
+		return
+	}
+	s.waiting[to]++
+	if s.state[to] == TaskReady {
+		s.state[to] = TaskPending
+		if s.since != nil {
+			delete(s.since, to)
+		}
+		for i, id := range s.frontier {
+			if id == to {
+				s.frontier = append(s.frontier[:i], s.frontier[i+1:]...)
+				break
+			}
+		}
+	}
+	if st := s.state[from]; st == TaskFailed || st == TaskBlocked {
+		s.block([]string{to})
+	}
+}
+
+// Restore marks tasks completed by an interrupted run as done and rebuilds the frontier. It must
+// be called before anything is dispatched; every other task starts over.
+func (s *Scheduler) Restore(done []string) error {
+	if s.running > 0 {
+		return errors.New("scheduler: cannot restore while tasks are running")
+	}
+	for _, id := range done {
+		if _, ok := s.tasks[id]; !ok {
+			return fmt.Errorf("scheduler: restored task %s is not in the graph", id)
+		}
+	}
+	for _, id := range s.ids {
+		s.state[id] = TaskPending
+		s.waiting[id] = 0
+	}
+	for _, id := range done {
+		s.state[id] = TaskDone
+		s.progress[id] = 100
+	}
+	for u, vs := range s.succs {
+		if s.state[u] == TaskDone {
+			continue
+		}
+		for _, v := range vs {
+			s.waiting[v]++
+		}
+	}
+	s.frontier = s.frontier[:0]
+	if s.since != nil {
+		s.since = map[string]time.Time{}
+	}
+	for _, id := range s.ids {
+		if s.state[id] == TaskPending && s.waiting[id] == 0 {
+			s.state[id] = TaskReady
+			s.frontier = append(s.frontier, id)
+		}
+	}
+	return nil
+}
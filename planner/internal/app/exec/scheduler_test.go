@@ -0,0 +1,228 @@
+package exec
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	m "github.com/james/tasks-planner/internal/model"
+)
+
+func chainCoordinator(ids []string, edges [][2]string) m.Coordinator {
+	var coord m.Coordinator
+	for _, id := range ids {
+		coord.Graph.Nodes = append(coord.Graph.Nodes, m.Task{ID: id})
+	}
+	for _, e := range edges {
+		coord.Graph.Edges = append(coord.Graph.Edges, m.Edge{From: e[0], To: e[1], Type: "technical", IsHard: true, Confidence: 1})
+	}
+	return coord
+}
+
+func taskIDs(tasks []m.Task) []string {
+	out := make([]string, len(tasks))
+	for i, t := range tasks {
+		out[i] = t.ID
+	}
+	return out
+}
+
+func TestSchedulerRollingFrontier(t *testing.T) {
+	coord := chainCoordinator([]string{"A", "B", "C", "D"}, [][2]string{{"A", "C"}, {"B", "C"}, {"C", "D"}})
+	coord.Graph.Edges = append(coord.Graph.Edges, m.Edge{From: "A", To: "B", Type: "resource", IsHard: true, Confidence: 1})
+	s, err := NewScheduler(coord)
+	if err != nil {
+		t.Fatalf("new scheduler: %v", err)
+	}
+	if got := taskIDs(s.Next()); len(got) != 2 || got[0] != "A" || got[1] != "B" {
+		t.Fatalf("expected A,B dispatched (resource edge ignored), got %v", got)
+	}
+	if err := s.Complete("A"); err != nil {
+		t.Fatalf("complete A: %v", err)
+	}
+	if got := s.Next(); len(got) != 0 {
+		t.Fatalf("C must wait for B, got %v", taskIDs(got))
+	}
+	if err := s.Complete("B"); err != nil {
+		t.Fatalf("complete B: %v", err)
+	}
+	if got := taskIDs(s.Next()); len(got) != 1 || got[0] != "C" {
+		t.Fatalf("expected C, got %v", got)
+	}
+	if err := s.Complete("C"); err != nil {
+		t.Fatalf("complete C: %v", err)
+	}
+	s.Next()
+	if err := s.Complete("D"); err != nil {
+		t.Fatalf("complete D: %v", err)
+	}
+	if !s.Finished() || len(s.Unfinished()) != 0 {
+		t.Fatalf("expected all tasks done, unfinished=%v", s.Unfinished())
+	}
+}
+
+func TestSchedulerConcurrencyLimit(t *testing.T) {
+	coord := chainCoordinator([]string{"A", "B", "C"}, nil)
+	coord.Config.Policies.ConcurrencyMax = 2
+	s, err := NewScheduler(coord)
+	if err != nil {
+		t.Fatalf("new scheduler: %v", err)
+	}
+	if got := s.Next(); len(got) != 2 {
+		t.Fatalf("expected 2 dispatched, got %d", len(got))
+	}
+	if got := s.Next(); len(got) != 0 {
+		t.Fatalf("limit exceeded: %v", taskIDs(got))
+	}
+	_ = s.Complete("A")
+	if got := taskIDs(s.Next()); len(got) != 1 || got[0] != "C" {
+		t.Fatalf("expected C after a slot frees, got %v", got)
+	}
+}
+
+func TestSchedulerFailureBlocksDependents(t *testing.T) {
+	coord := chainCoordinator([]string{"A", "B", "C", "D"}, [][2]string{{"A", "B"}, {"B", "C"}})
+	s, err := NewScheduler(coord)
+	if err != nil {
+		t.Fatalf("new scheduler: %v", err)
+	}
+	s.Next()
+	if err := s.Fail("A"); err != nil {
+		t.Fatalf("fail A: %v", err)
+	}
+	_ = s.Complete("D")
+	for id, want := range map[string]TaskState{"A": TaskFailed, "B": TaskBlocked, "C": TaskBlocked, "D": TaskDone} {
+		if got, _ := s.State(id); got != want {
+			t.Fatalf("%s: expected %s, got %s", id, want, got)
+		}
+	}
+	if !s.Finished() {
+		t.Fatalf("expected scheduler to drain after failure")
+	}
+}
+
+func TestSchedulerRejectsCycles(t *testing.T) {
+	coord := chainCoordinator([]string{"A", "B"}, [][2]string{{"A", "B"}, {"B", "A"}})
+	if _, err := NewScheduler(coord); !errors.Is(err, ErrCycle) {
+		t.Fatalf("expected ErrCycle, got %v", err)
+	}
+}
+
+func TestSchedulerRejectsUnknownEdgeEndpoint(t *testing.T) {
+	coord := chainCoordinator([]string{"A"}, [][2]string{{"A", "Z"}})
+	if _, err := NewScheduler(coord); err == nil {
+		t.Fatalf("expected error for unknown task")
+	}
+}
+
+func TestSchedulerExtendGatesNewWork(t *testing.T) {
+	s, err := NewScheduler(chainCoordinator([]string{"A", "B"}, nil))
+	if err != nil {
+		t.Fatalf("new scheduler: %v", err)
+	}
+	if got := taskIDs(s.Next()); len(got) != 2 {
+		t.Fatalf("expected A,B dispatched, got %v", got)
+	}
+	if err := s.Fail("A"); err != nil {
+		t.Fatalf("fail A: %v", err)
+	}
+	edge := func(from, to string) m.Edge {
+		return m.Edge{From: from, To: to, Type: "technical", IsHard: true, Confidence: 1}
+	}
+	if err := s.Extend(nil, []m.Edge{edge("B", "A")}); err == nil {
+		t.Fatal("expected edge into a finished task to be refused")
+	}
+	tasks := []m.Task{{ID: "DEPS"}, {ID: "UI"}, {ID: "X"}}
+	if err := s.Extend(tasks, []m.Edge{edge("B", "DEPS"), edge("DEPS", "UI"), edge("A", "X")}); err != nil {
+		t.Fatalf("extend: %v", err)
+	}
+	if st, _ := s.State("X"); st != TaskBlocked {
+		t.Fatalf("expected X blocked behind failed A, got %s", st)
+	}
+	if got := s.Next(); len(got) != 0 {
+		t.Fatalf("expected nothing ready before B completes, got %v", taskIDs(got))
+	}
+	if err := s.Complete("B"); err != nil {
+		t.Fatalf("complete B: %v", err)
+	}
+	if got := taskIDs(s.Next()); len(got) != 1 || got[0] != "DEPS" {
+		t.Fatalf("expected DEPS dispatched, got %v", got)
+	}
+}
+
+func TestSchedulerRequeueReturnsTaskToFrontier(t *testing.T) {
+	s, err := NewScheduler(chainCoordinator([]string{"A", "B"}, [][2]string{{"A", "B"}}))
+	if err != nil {
+		t.Fatalf("new scheduler: %v", err)
+	}
+	s.Next()
+	if plan := s.Compensation("A"); len(plan) != 1 || plan[0] != "A" {
+		t.Fatalf("expected only A to compensate, got %v", plan)
+	}
+	if err := s.Requeue("A", []string{"A"}); err != nil {
+		t.Fatalf("requeue: %v", err)
+	}
+	if st, _ := s.State("A"); st != TaskReady {
+		t.Fatalf("expected A ready again, got %s", st)
+	}
+	if st, _ := s.State("B"); st != TaskPending {
+		t.Fatalf("expected B still pending, got %s", st)
+	}
+	if got := taskIDs(s.Next()); len(got) != 1 || got[0] != "A" {
+		t.Fatalf("expected A redispatched, got %v", got)
+	}
+}
+
+func TestSchedulerCompensationCoversSharedWritesAndInterfaces(t *testing.T) {
+	coord := chainCoordinator([]string{"A", "B", "C", "D", "E"}, [][2]string{{"A", "B"}, {"B", "E"}, {"C", "E"}})
+	coord.Graph.Nodes[0].Resources.Exclusive = []string{"db"}
+	coord.Graph.Nodes[2].InterfacesConsumed = []m.InterfaceConsumed{{Name: "users-api"}}
+	coord.Graph.Nodes[4].Resources.Exclusive = []string{"db"}
+	coord.Graph.Nodes[4].InterfacesProduced = []m.InterfaceProduced{{Name: "users-api"}}
+	s, err := NewScheduler(coord)
+	if err != nil {
+		t.Fatalf("new scheduler: %v", err)
+	}
+	for _, step := range [][]string{{"A", "C", "D"}, {"B"}} {
+		s.Next()
+		for _, id := range step {
+			if err := s.Complete(id); err != nil {
+				t.Fatalf("complete %s: %v", id, err)
+			}
+		}
+	}
+	s.Next()
+	// E shares db with A, which B depends on, and publishes the interface C consumes; D is
+	// unrelated and keeps its effects.
+	plan := s.Compensation("E")
+	if want := []string{"E", "C", "B", "A"}; !reflect.DeepEqual(plan, want) {
+		t.Fatalf("compensation = %v, want %v", plan, want)
+	}
+	if err := s.Requeue("E", plan); err != nil {
+		t.Fatalf("requeue: %v", err)
+	}
+	for id, want := range map[string]TaskState{"A": TaskReady, "B": TaskPending, "C": TaskReady, "D": TaskDone, "E": TaskPending} {
+		if st, _ := s.State(id); st != want {
+			t.Fatalf("%s: state %s, want %s", id, st, want)
+		}
+	}
+}
+
+func TestSchedulerRestoreRebuildsFrontier(t *testing.T) {
+	s, err := NewScheduler(chainCoordinator([]string{"A", "B", "C"}, [][2]string{{"A", "B"}, {"B", "C"}}))
+	if err != nil {
+		t.Fatalf("new scheduler: %v", err)
+	}
+	if err := s.Restore([]string{"A"}); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if got := taskIDs(s.Next()); len(got) != 1 || got[0] != "B" {
+		t.Fatalf("expected B ready after restoring A, got %v", got)
+	}
+	if err := s.Restore(nil); err == nil {
+		t.Fatal("expected restore to refuse while B is running")
+	}
+	if err := s.Restore([]string{"Z"}); err == nil {
+		t.Fatal("expected unknown task to be rejected")
+	}
+}
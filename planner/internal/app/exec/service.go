@@ -5,6 +5,7 @@ import (
 	"errors"
 
 	m "github.com/james/tasks-planner/internal/model"
+	"github.com/james/tasks-planner/internal/provenance"
 )
 
 // Service orchestrates executor initialization and runtime.
@@ -12,6 +13,14 @@ type Service struct {
 	LoadCoordinator func(path string) (m.Coordinator, error)
 	InitRuntime     func(ctx context.Context, coord m.Coordinator) error
 	RunLoop         func(ctx context.Context) error
+	// ApplyPatch hot-patches the running plan (optional).
+	ApplyPatch func(p m.Patch) (PatchResult, error)
+	// Graph, Breakers and Provenance let operators inspect the running plan (optional).
+	Graph      func() (GraphSnapshot, error)
+	Breakers   func() []BreakerState
+	Provenance func(q provenance.Query) ([]provenance.Record, error)
+	// AuditAdmin records an operator write in provenance (optional).
+	AuditAdmin func(a AdminAction) error
 }
 
 // Run loads the coordinator contract, initializes runtime components, then enters the execution loop.
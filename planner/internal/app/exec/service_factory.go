@@ -5,13 +5,62 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"sync"
+	"time"
 
+	"github.com/james/tasks-planner/internal/acceptance"
 	m "github.com/james/tasks-planner/internal/model"
+	"github.com/james/tasks-planner/internal/provenance"
+	"github.com/james/tasks-planner/internal/telemetry"
 )
 
-// ErrLoopNotImplemented is returned by the default loop placeholder.
-var ErrLoopNotImplemented = errors.New("executor loop not implemented")
+// Config carries executor options supplied by the slapsd CLI.
+type Config struct {
+	// WorkerCmd is the shell command invoked once per task; the task JSON is streamed on stdin.
+	WorkerCmd string
+	// Workers declares local workers running WorkerCmd; tasks are routed to one offering every
+	// capability they require. Empty keeps a single unrestricted runner and ignores capabilities.
+	Workers []WorkerSpec
+	// CheckDir is the working directory for acceptance checks (defaults to the process cwd).
+	CheckDir string
+	// Profile selects Config.Resources.Profiles entry overlaid onto the catalog (empty keeps it).
+	Profile string
+	// Stdout and Stderr receive worker output (discarded when nil).
+	Stdout io.Writer
+	Stderr io.Writer
+	// LedgerPath is the append-only provenance ledger (disabled when empty).
+	LedgerPath string
+	// TelemetryDir keeps each task's raw JSONL telemetry (not persisted when empty).
+	TelemetryDir string
+	// BreakerPath persists circuit breaker state across restarts (not persisted when empty).
+	BreakerPath string
+	// StateDir keeps checkpoints keyed by coordinator hash so a run can resume (disabled when empty).
+	StateDir string
+	// Resume continues the run checkpointed in StateDir instead of starting over. It is refused
+	// when the coordinator or Profile no longer matches the checkpoint.
+	Resume bool
+	// MaxCompensations overrides Policies.MaxCompensations: how often a failed non-idempotent task
+	// whose retry policy allows no retries is rolled back and requeued (the coordinator's value,
+	// or 1, when zero).
+	MaxCompensations int
+	// OnResume is told which state a resumed run continues from (optional).
+	OnResume func(ResumeSummary)
+	// PatchDir is polled for hot patch files while the loop runs (disabled when empty).
+	PatchDir string
+	// OnPatch is told about every patch file applied or rejected (optional).
+	OnPatch func(file string, res PatchResult, err error)
+	// Notify observes scheduler transitions (optional).
+	Notify func(Event)
+	// OnBreaker observes circuit breakers opening and closing (optional).
+	OnBreaker func(BreakerState)
+	// OnTelemetryViolation is told about non-conforming worker telemetry (optional).
+	OnTelemetryViolation func(task m.Task, v telemetry.Violation)
+	// Now is the runtime clock that stamps every ledger record, admin ones included (time.Now
+	// when nil).
+	Now func() time.Time
+}
 
 // FilesystemCoordinatorLoader reads coordinator contracts from disk.
 type FilesystemCoordinatorLoader struct {
@@ -39,11 +88,166 @@ func (l FilesystemCoordinatorLoader) read(path string) ([]byte, error) {
 }
 
 // NewDefaultService assembles the executor service with default adapters.
-func NewDefaultService() Service {
+func NewDefaultService(cfg Config) Service {
 	loader := FilesystemCoordinatorLoader{}
+	var poolErr error
+	rt := &Runtime{
+		Profile:          cfg.Profile,
+		Notify:           cfg.Notify,
+		Now:              cfg.Now,
+		BreakerPath:      cfg.BreakerPath,
+		OnBreaker:        cfg.OnBreaker,
+		StateDir:         cfg.StateDir,
+		Compensator:      RollbackRunner{Dir: cfg.CheckDir, Stdout: cfg.Stdout, Stderr: cfg.Stderr}.Run,
+		MaxCompensations: cfg.MaxCompensations,
+	}
+	if cfg.WorkerCmd != "" {
+		monitor := &TelemetryMonitor{
+			Dir:         cfg.TelemetryDir,
+			Progress:    rt.SetProgress,
+			OnLine:      rt.ObserveTelemetry,
+			OnViolation: cfg.OnTelemetryViolation,
+		}
+		rt.NonConforming = monitor.NonConforming
+		worker := CommandRunner{Command: cfg.WorkerCmd, Stdout: cfg.Stdout, Stderr: cfg.Stderr, Telemetry: monitor}
+		rt.Runner = WithAcceptance(worker.Run, acceptance.Engine{Dir: cfg.CheckDir})
+		if len(cfg.Workers) > 0 {
+			workers := make([]Worker, 0, len(cfg.Workers))
+			for _, ws := range cfg.Workers {
+				workers = append(workers, LocalWorker{Name: ws.ID, Caps: ws.Capabilities, Runner: worker})
+			}
+			pool, err := NewWorkerPool(workers...)
+			if err != nil {
+				poolErr = err
+			} else {
+				rt.Workers = pool
+				rt.Runner = WithAcceptance(pool.Run, acceptance.Engine{Dir: cfg.CheckDir})
+			}
+		}
+	}
+	var (
+		ledgerMu sync.Mutex
+		ledger   *provenance.Ledger
+		recorder *ProvenanceRecorder
+	)
+	currentLedger := func() (*provenance.Ledger, *ProvenanceRecorder) {
+		ledgerMu.Lock()
+		defer ledgerMu.Unlock()
+		return ledger, recorder
+	}
 	return Service{
 		LoadCoordinator: loader.Load,
-		InitRuntime:     func(ctx context.Context, coord m.Coordinator) error { return nil },
-		RunLoop:         func(ctx context.Context) error { return ErrLoopNotImplemented },
+		InitRuntime: func(ctx context.Context, coord m.Coordinator) error {
+			if poolErr != nil {
+				return poolErr
+			}
+			var (
+				cp      Checkpoint
+				records []provenance.Record
+			)
+			if cfg.Resume {
+				var err error
+				if cp, records, err = loadResumeState(cfg, coord); err != nil {
+					return err
+				}
+			}
+			if cfg.LedgerPath != "" {
+				l, err := provenance.Open(cfg.LedgerPath)
+				if err != nil {
+					return err
+				}
+				ledgerMu.Lock()
+				ledger = l
+				recorder = &ProvenanceRecorder{Ledger: l, Dir: cfg.CheckDir, TelemetryDir: cfg.TelemetryDir}
+				ledgerMu.Unlock()
+				rt.Recorder = recorder
+			}
+			if err := rt.Init(ctx, coord); err != nil {
+				closeLedger(ledger)
+				return err
+			}
+			if cfg.Resume {
+				sum, err := rt.Resume(cp, records)
+				if err != nil {
+					closeLedger(ledger)
+					return err
+				}
+				if cfg.OnResume != nil {
+					cfg.OnResume(sum)
+				}
+			}
+			return nil
+		},
+		RunLoop: func(ctx context.Context) error {
+			defer closeLedger(ledger)
+			if cfg.PatchDir != "" {
+				watchCtx, stop := context.WithCancel(ctx)
+				done := make(chan struct{})
+				go func() {
+					defer close(done)
+					PatchWatcher{Dir: cfg.PatchDir, Apply: rt.ApplyPatch, Report: cfg.OnPatch}.Run(watchCtx)
+				}()
+				defer func() {
+					stop()
+					<-done
+				}()
+			}
+			return rt.Run(ctx)
+		},
+		ApplyPatch: rt.ApplyPatch,
+		Graph:      rt.Graph,
+		Breakers:   rt.Breakers,
+		Provenance: func(q provenance.Query) ([]provenance.Record, error) {
+			l, _ := currentLedger()
+			if l == nil {
+				return nil, errors.New("provenance ledger not enabled")
+			}
+			return l.Records(q)
+		},
+		AuditAdmin: func(a AdminAction) error {
+			_, rec := currentLedger()
+			if rec == nil {
+				return errors.New("provenance ledger not enabled")
+			}
+			a.Time = rt.now()
+			return rec.RecordAdmin(a)
+		},
+	}
+}
+
+// loadResumeState reads the checkpoint for coord and the ledger records it may be missing.
+func loadResumeState(cfg Config, coord m.Coordinator) (Checkpoint, []provenance.Record, error) {
+	if cfg.StateDir == "" {
+		return Checkpoint{}, nil, fmt.Errorf("resume: no state dir configured")
+	}
+	h, err := CoordinatorHash(coord)
+	if err != nil {
+		return Checkpoint{}, nil, err
+	}
+	cp, err := CheckpointStore{Dir: cfg.StateDir, Hash: h}.Load()
+	if err != nil {
+		return Checkpoint{}, nil, fmt.Errorf("resume: %w", err)
+	}
+	if cfg.LedgerPath == "" {
+		return cp, nil, nil
+	}
+	f, err := os.Open(cfg.LedgerPath)
+	if os.IsNotExist(err) {
+		return cp, nil, nil
+	}
+	if err != nil {
+		return cp, nil, fmt.Errorf("resume: read ledger: %w", err)
+	}
+	defer f.Close()
+	records, err := provenance.ReadAll(f)
+	if err != nil {
+		return cp, nil, fmt.Errorf("resume: read ledger: %w", err)
+	}
+	return cp, records, nil
+}
+
+func closeLedger(l *provenance.Ledger) {
+	if l != nil {
+		_ = l.Close()
 	}
 }
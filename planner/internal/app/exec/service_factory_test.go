@@ -5,9 +5,13 @@ import (
 	"errors"
 	"os"
 	"path/filepath"
+	"runtime"
 	"testing"
+	"time"
 
 	execapp "github.com/james/tasks-planner/internal/app/exec"
+	m "github.com/james/tasks-planner/internal/model"
+	"github.com/james/tasks-planner/internal/provenance"
 )
 
 func TestNewDefaultServiceLoadsCoordinator(t *testing.T) {
@@ -17,16 +21,72 @@ func TestNewDefaultServiceLoadsCoordinator(t *testing.T) {
 		t.Fatalf("write coord: %v", err)
 	}
 
-	svc := execapp.NewDefaultService()
-	err := svc.Run(context.Background(), coordPath)
-	if !errors.Is(err, execapp.ErrLoopNotImplemented) {
-		t.Fatalf("expected ErrLoopNotImplemented, got %v", err)
+	svc := execapp.NewDefaultService(execapp.Config{WorkerCmd: "exit 0"})
+	if err := svc.Run(context.Background(), coordPath); err != nil {
+		t.Fatalf("expected empty plan to run cleanly, got %v", err)
 	}
 }
 
 func TestNewDefaultServiceMissingFile(t *testing.T) {
-	svc := execapp.NewDefaultService()
+	svc := execapp.NewDefaultService(execapp.Config{WorkerCmd: "exit 0"})
 	if err := svc.Run(context.Background(), "nope.json"); err == nil {
 		t.Fatalf("expected error for missing coordinator")
 	}
 }
+
+func TestNewDefaultServiceRequiresWorker(t *testing.T) {
+	dir := t.TempDir()
+	coordPath := filepath.Join(dir, "coord.json")
+	if err := os.WriteFile(coordPath, []byte(`{"version":"v8"}`), 0o644); err != nil {
+		t.Fatalf("write coord: %v", err)
+	}
+	svc := execapp.NewDefaultService(execapp.Config{})
+	if err := svc.Run(context.Background(), coordPath); err == nil {
+		t.Fatalf("expected error without a worker command")
+	}
+}
+
+func TestNewDefaultServiceRunsWorkerCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("worker command uses POSIX shell")
+	}
+	dir := t.TempDir()
+	coordPath := filepath.Join(dir, "coord.json")
+	coord := `{"version":"v8","graph":{"nodes":[{"id":"T001"},{"id":"T002"}],"edges":[{"from":"T001","to":"T002","type":"technical","isHard":true,"confidence":1}]}}`
+	if err := os.WriteFile(coordPath, []byte(coord), 0o644); err != nil {
+		t.Fatalf("write coord: %v", err)
+	}
+	svc := execapp.NewDefaultService(execapp.Config{WorkerCmd: `test "$TASKS_TASK_ID" != T002`})
+	err := svc.Run(context.Background(), coordPath)
+	if !errors.Is(err, execapp.ErrTasksFailed) {
+		t.Fatalf("expected ErrTasksFailed, got %v", err)
+	}
+}
+
+func TestNewDefaultServiceStampsAdminActionsWithRuntimeClock(t *testing.T) {
+	dir := t.TempDir()
+	clock := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	svc := execapp.NewDefaultService(execapp.Config{
+		WorkerCmd:  "exit 0",
+		LedgerPath: filepath.Join(dir, "provenance.jsonl"),
+		Now:        func() time.Time { return clock },
+	})
+	if err := svc.InitRuntime(context.Background(), m.Coordinator{}); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	err := svc.AuditAdmin(execapp.AdminAction{AssertedOperator: "mallory", Verb: "patch", Time: time.Unix(0, 0)})
+	if err != nil {
+		t.Fatalf("audit: %v", err)
+	}
+	recs, err := svc.Provenance(provenance.Query{Kind: provenance.KindAdmin})
+	if err != nil || len(recs) != 1 {
+		t.Fatalf("expected one admin record, got %+v (%v)", recs, err)
+	}
+	rec := recs[0]
+	if rec.Time != "2024-05-01T12:00:00Z" {
+		t.Fatalf("expected the runtime clock's time, got %s", rec.Time)
+	}
+	if rec.Data["principal"] != execapp.AdminPrincipal || rec.Data["asserted_operator"] != "mallory" || rec.Data["operator"] != nil {
+		t.Fatalf("expected the operator recorded as asserted only, got %v", rec.Data)
+	}
+}
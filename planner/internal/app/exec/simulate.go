@@ -0,0 +1,279 @@
+package exec
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	m "github.com/james/tasks-planner/internal/model"
+	"github.com/james/tasks-planner/internal/pert"
+)
+
+// ErrSimulationStalled is returned when simulated tasks can never be dispatched.
+var ErrSimulationStalled = errors.New("simulation stalled")
+
+// SimOptions configures a dry run.
+type SimOptions struct {
+	// Profile is overlaid onto the catalog as for a real run.
+	Profile string
+	// Seed makes duration sampling reproducible.
+	Seed int64
+	// Workers simulates capability-matched workers; when empty, ConcurrencyMax anonymous slots are
+	// used (unlimited when it is zero).
+	Workers []WorkerSpec
+}
+
+// SimTask is one simulated task execution; times are hours from the start of the run.
+type SimTask struct {
+	ID       string  `json:"id"`
+	Worker   string  `json:"worker,omitempty"`
+	Start    float64 `json:"start"`
+	Finish   float64 `json:"finish"`
+	Duration float64 `json:"duration"`
+}
+
+// SimResource reports how busy a resource was. Exclusive locks occupy the whole resource.
+type SimResource struct {
+	Name          string  `json:"name"`
+	Capacity      int     `json:"capacity"`
+	Peak          int     `json:"peak"`
+	BusyUnitHours float64 `json:"busy_unit_hours"`
+	Utilization   float64 `json:"utilization"`
+}
+
+// SimIdle is a span during which Idle worker slots had nothing to run.
+type SimIdle struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Idle  int     `json:"idle"`
+}
+
+// SimTimeline is the result of Simulate.
+type SimTimeline struct {
+	Seed            int64         `json:"seed"`
+	Profile         string        `json:"profile,omitempty"`
+	Workers         int           `json:"workers"`
+	Makespan        float64       `json:"makespan_hours"`
+	WorkerHours     float64       `json:"worker_hours"`
+	IdleWorkerHours float64       `json:"idle_worker_hours"`
+	Utilization     float64       `json:"worker_utilization"`
+	Tasks           []SimTask     `json:"tasks"`
+	Resources       []SimResource `json:"resources"`
+	Idle            []SimIdle     `json:"idle"`
+}
+
+// simEpoch anchors the virtual clock; only differences matter.
+var simEpoch = time.Unix(0, 0).UTC()
+
+// Simulate runs the scheduler against coord on a virtual clock: nothing executes, every task
+// succeeds after a duration sampled from its DurationPERT, and dispatch honours the same priority,
+// lock, quota and worker gates as a real run (circuit breakers and retries never trigger).
+func Simulate(coord m.Coordinator, opts SimOptions) (SimTimeline, error) {
+	tl := SimTimeline{Seed: opts.Seed, Profile: opts.Profile, Tasks: []SimTask{}, Resources: []SimResource{}, Idle: []SimIdle{}}
+	coord, err := ApplyProfile(coord, opts.Profile)
+	if err != nil {
+		return tl, err
+	}
+	sched, err := NewScheduler(coord)
+	if err != nil {
+		return tl, err
+	}
+	quotas, err := NewQuotaManager(coord)
+	if err != nil {
+		return tl, err
+	}
+	prio, err := NewWeightedPrioritizer(coord)
+	if err != nil {
+		return tl, err
+	}
+	now := 0.0
+	sched.SetPrioritizer(prio, func() time.Time { return simEpoch.Add(hours(now)) })
+	locks := NewLockManager(coord)
+	sched.AddGate(locks)
+	sched.AddGate(quotas)
+	var pool *WorkerPool
+	slots := coord.Config.Policies.ConcurrencyMax
+	if len(opts.Workers) > 0 {
+		workers := make([]Worker, 0, len(opts.Workers))
+		for _, ws := range opts.Workers {
+			workers = append(workers, &MemoryWorker{Name: ws.ID, Caps: ws.Capabilities})
+		}
+		if pool, err = NewWorkerPool(workers...); err != nil {
+			return tl, err
+		}
+		if err := pool.Check(coord.Graph.Nodes); err != nil {
+			return tl, err
+		}
+		sched.AddGate(pool)
+		if slots <= 0 || slots > len(workers) {
+			slots = len(workers)
+		}
+	}
+
+	usage := newSimUsage(coord)
+	rng := rand.New(rand.NewSource(opts.Seed))
+	var running []SimTask
+	type span struct {
+		from, to float64
+		busy     int
+	}
+	var spans []span // idle slots are only known once the run is over
+	peak := 0
+	for {
+		for _, task := range sched.Next() {
+			d := pert.Hours(task, pert.Sample(rng, task.Duration))
+			run := SimTask{ID: task.ID, Start: now, Finish: now + d, Duration: d}
+			if pool != nil {
+				run.Worker = pool.Assigned(task.ID)
+			}
+			running = append(running, run)
+			usage.acquire(task, quotas)
+		}
+		if len(running) == 0 {
+			break
+		}
+		if len(running) > peak {
+			peak = len(running)
+		}
+		sort.Slice(running, func(i, j int) bool {
+			if running[i].Finish != running[j].Finish {
+				return running[i].Finish < running[j].Finish
+			}
+			return running[i].ID < running[j].ID
+		})
+		next := running[0]
+		usage.advance(next.Finish - now)
+		spans = append(spans, span{from: now, to: next.Finish, busy: len(running)})
+		now = next.Finish
+		running = running[1:]
+		task, _ := sched.Task(next.ID)
+		if err := sched.Complete(next.ID); err != nil {
+			return tl, err
+		}
+		usage.release(task)
+		tl.Tasks = append(tl.Tasks, next)
+	}
+	if left := sched.Unfinished(); len(left) > 0 {
+		return tl, fmt.Errorf("%w: %s can never be dispatched", ErrSimulationStalled, strings.Join(left, ", "))
+	}
+
+	if slots <= 0 {
+		// Unlimited concurrency: size the pool by what the run actually used.
+		slots = peak
+	}
+	for _, sp := range spans {
+		tl.recordIdle(sp.from, sp.to, slots, sp.busy)
+	}
+	sort.SliceStable(tl.Tasks, func(i, j int) bool {
+		if tl.Tasks[i].Start != tl.Tasks[j].Start {
+			return tl.Tasks[i].Start < tl.Tasks[j].Start
+		}
+		return tl.Tasks[i].ID < tl.Tasks[j].ID
+	})
+	tl.Workers = slots
+	tl.Makespan = now
+	for _, t := range tl.Tasks {
+		tl.WorkerHours += t.Duration
+	}
+	if slots > 0 && now > 0 {
+		tl.IdleWorkerHours = float64(slots)*now - tl.WorkerHours
+		tl.Utilization = tl.WorkerHours / (float64(slots) * now)
+	}
+	tl.Resources = usage.report(now)
+	return tl, nil
+}
+
+func hours(h float64) time.Duration {
+	return time.Duration(h * float64(time.Hour))
+}
+
+// recordIdle notes idle slots over [from, to), merging with the previous span when unchanged.
+func (tl *SimTimeline) recordIdle(from, to float64, slots, busy int) {
+	if slots <= 0 || to <= from || busy >= slots {
+		return
+	}
+	idle := slots - busy
+	if n := len(tl.Idle); n > 0 && tl.Idle[n-1].End == from && tl.Idle[n-1].Idle == idle {
+		tl.Idle[n-1].End = to
+		return
+	}
+	tl.Idle = append(tl.Idle, SimIdle{Start: from, End: to, Idle: idle})
+}
+
+// simUsage tracks units held per resource while simulated time advances.
+type simUsage struct {
+	capacity map[string]int
+	held     map[string]int
+	peak     map[string]int
+	busy     map[string]float64
+	holds    map[string]map[string]int
+}
+
+func newSimUsage(coord m.Coordinator) *simUsage {
+	u := &simUsage{
+		capacity: map[string]int{},
+		held:     map[string]int{},
+		peak:     map[string]int{},
+		busy:     map[string]float64{},
+		holds:    map[string]map[string]int{},
+	}
+	for name, spec := range coord.Config.Resources.Catalog {
+		u.capacity[name] = spec.Capacity
+	}
+	for _, t := range coord.Graph.Nodes {
+		for _, name := range t.Resources.Exclusive {
+			if _, ok := u.capacity[name]; !ok {
+				u.capacity[name] = 1
+			}
+		}
+	}
+	return u
+}
+
+func (u *simUsage) acquire(task m.Task, quotas *QuotaManager) {
+	units := map[string]int{}
+	for _, name := range task.Resources.Exclusive {
+		units[name] = u.capacity[name]
+	}
+	for _, need := range quotas.needs(task) {
+		if units[need.Name] < need.Units {
+			units[need.Name] = need.Units
+		}
+	}
+	for name, n := range units {
+		u.held[name] += n
+		if u.held[name] > u.peak[name] {
+			u.peak[name] = u.held[name]
+		}
+	}
+	u.holds[task.ID] = units
+}
+
+func (u *simUsage) release(task m.Task) {
+	for name, n := range u.holds[task.ID] {
+		u.held[name] -= n
+	}
+	delete(u.holds, task.ID)
+}
+
+func (u *simUsage) advance(dt float64) {
+	for name, n := range u.held {
+		u.busy[name] += float64(n) * dt
+	}
+}
+
+func (u *simUsage) report(makespan float64) []SimResource {
+	out := make([]SimResource, 0, len(u.capacity))
+	for name, capacity := range u.capacity {
+		r := SimResource{Name: name, Capacity: capacity, Peak: u.peak[name], BusyUnitHours: u.busy[name]}
+		if capacity > 0 && makespan > 0 {
+			r.Utilization = r.BusyUnitHours / (float64(capacity) * makespan)
+		}
+		out = append(out, r)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
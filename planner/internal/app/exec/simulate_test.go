@@ -0,0 +1,83 @@
+package exec
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	m "github.com/james/tasks-planner/internal/model"
+)
+
+func fixedDurations(coord *m.Coordinator, hours map[string]float64) {
+	for i := range coord.Graph.Nodes {
+		h := hours[coord.Graph.Nodes[i].ID]
+		coord.Graph.Nodes[i].Duration = m.DurationPERT{Optimistic: h, MostLikely: h, Pessimistic: h}
+	}
+}
+
+func TestSimulateHonoursConcurrencyAndReportsIdle(t *testing.T) {
+	// A and B can run together, C waits on A.
+	coord := chainCoordinator([]string{"A", "B", "C"}, [][2]string{{"A", "C"}})
+	fixedDurations(&coord, map[string]float64{"A": 2, "B": 1, "C": 3})
+	coord.Config.Policies.ConcurrencyMax = 2
+	tl, err := Simulate(coord, SimOptions{Seed: 1})
+	if err != nil {
+		t.Fatalf("simulate: %v", err)
+	}
+	if tl.Makespan != 5 || tl.Workers != 2 {
+		t.Fatalf("expected makespan 5 on 2 workers, got %+v", tl)
+	}
+	start := map[string]float64{}
+	for _, task := range tl.Tasks {
+		start[task.ID] = task.Start
+	}
+	if start["A"] != 0 || start["B"] != 0 || start["C"] != 2 {
+		t.Fatalf("unexpected starts %v", start)
+	}
+	// One slot idles from 1h (B done) to 2h, then from 2h to 5h while only C runs.
+	if tl.IdleWorkerHours != 4 || len(tl.Idle) != 1 || tl.Idle[0] != (SimIdle{Start: 1, End: 5, Idle: 1}) {
+		t.Fatalf("unexpected idle accounting %v (%g hours)", tl.Idle, tl.IdleWorkerHours)
+	}
+}
+
+func TestSimulateSerializesLocksAndMeasuresUtilization(t *testing.T) {
+	coord := chainCoordinator([]string{"A", "B"}, nil)
+	fixedDurations(&coord, map[string]float64{"A": 1, "B": 1})
+	for i := range coord.Graph.Nodes {
+		coord.Graph.Nodes[i].Resources.Exclusive = []string{"db"}
+	}
+	tl, err := Simulate(coord, SimOptions{})
+	if err != nil {
+		t.Fatalf("simulate: %v", err)
+	}
+	if tl.Makespan != 2 || tl.Workers != 1 {
+		t.Fatalf("expected the lock to serialize the tasks, got %+v", tl)
+	}
+	if len(tl.Resources) != 1 || tl.Resources[0].Utilization != 1 || tl.Resources[0].Peak != 1 {
+		t.Fatalf("unexpected resource usage %+v", tl.Resources)
+	}
+}
+
+func TestSimulateIsReproducibleAndRoutesWorkers(t *testing.T) {
+	coord := chainCoordinator([]string{"API", "UI"}, [][2]string{{"API", "UI"}})
+	coord.Graph.Nodes[0].Duration = m.DurationPERT{Optimistic: 1, MostLikely: 2, Pessimistic: 5}
+	coord.Graph.Nodes[1].Duration = m.DurationPERT{Optimistic: 1, MostLikely: 1, Pessimistic: 4}
+	coord.Graph.Nodes[1].Capabilities = []string{"frontend"}
+	opts := SimOptions{Seed: 42, Workers: []WorkerSpec{{ID: "go-1", Capabilities: []string{"go"}}, {ID: "web-1", Capabilities: []string{"frontend"}}}}
+	a, err := Simulate(coord, opts)
+	if err != nil {
+		t.Fatalf("simulate: %v", err)
+	}
+	b, _ := Simulate(coord, opts)
+	if !reflect.DeepEqual(a, b) {
+		t.Fatal("same seed should give the same timeline")
+	}
+	if a.Tasks[1].Worker != "web-1" {
+		t.Fatalf("expected UI on web-1, got %+v", a.Tasks)
+	}
+
+	opts.Workers = opts.Workers[:1]
+	if _, err := Simulate(coord, opts); !errors.Is(err, ErrNoCapableWorker) {
+		t.Fatalf("expected ErrNoCapableWorker, got %v", err)
+	}
+}
@@ -0,0 +1,122 @@
+package exec
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	m "github.com/james/tasks-planner/internal/model"
+	"github.com/james/tasks-planner/internal/telemetry"
+)
+
+// TelemetryMonitor consumes worker stdout for tasks whose ExecutionLogging.Format is JSONL,
+// validating it against the task's required fields and feeding progress and error lines to the
+// runtime. Workers that emit non-conforming lines are flagged rather than failed.
+type TelemetryMonitor struct {
+	// Dir, when set, keeps each task's raw stream at TelemetryPath(Dir, taskID).
+	Dir         string
+	Progress    func(taskID string, pct float64)
+	OnLine      func(task m.Task, line telemetry.Line)
+	OnViolation func(task m.Task, v telemetry.Violation)
+
+	mu      sync.Mutex
+	flagged map[string]int
+}
+
+// TelemetryPath is where the monitor stores a task's raw telemetry under dir.
+func TelemetryPath(dir, taskID string) string {
+	return filepath.Join(dir, taskID+".jsonl")
+}
+
+// Enabled reports whether task declared JSONL execution logging.
+func (t *TelemetryMonitor) Enabled(task m.Task) bool {
+	return strings.EqualFold(strings.TrimSpace(task.ExecutionLogging.Format), "JSONL")
+}
+
+// Watch returns a writer for the task's stdout and a wait function that closes the stream and
+// returns the validated summary once every line has been consumed.
+func (t *TelemetryMonitor) Watch(task m.Task) (io.Writer, func() (telemetry.Summary, error), error) {
+	var sink *os.File
+	if t.Dir != "" {
+		if err := os.MkdirAll(t.Dir, 0o755); err != nil {
+			return nil, nil, fmt.Errorf("telemetry dir: %w", err)
+		}
+		f, err := os.Create(TelemetryPath(t.Dir, task.ID))
+		if err != nil {
+			return nil, nil, fmt.Errorf("telemetry file: %w", err)
+		}
+		sink = f
+	}
+	pr, pw := io.Pipe()
+	reader := telemetry.Reader{
+		TaskID:         task.ID,
+		RequiredFields: task.ExecutionLogging.RequiredFields,
+		OnProgress: func(pct float64) {
+			if t.Progress != nil {
+				t.Progress(task.ID, pct)
+			}
+		},
+		OnLine: func(line telemetry.Line) {
+			if t.OnLine != nil {
+				t.OnLine(task, line)
+			}
+		},
+		OnViolation: func(v telemetry.Violation) {
+			t.flag(task.ID)
+			if t.OnViolation != nil {
+				t.OnViolation(task, v)
+			}
+		},
+	}
+	type outcome struct {
+		sum telemetry.Summary
+		err error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		sum, err := reader.Consume(pr)
+		// Keep draining so the worker never blocks on a full pipe after a scan error.
+		_, _ = io.Copy(io.Discard, pr)
+		done <- outcome{sum: sum, err: err}
+	}()
+	var w io.Writer = pw
+	if sink != nil {
+		w = io.MultiWriter(pw, sink)
+	}
+	wait := func() (telemetry.Summary, error) {
+		_ = pw.Close()
+		res := <-done
+		if sink != nil {
+			if err := sink.Close(); err != nil && res.err == nil {
+				res.err = err
+			}
+		}
+		return res.sum, res.err
+	}
+	return w, wait, nil
+}
+
+func (t *TelemetryMonitor) flag(taskID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.flagged == nil {
+		t.flagged = map[string]int{}
+	}
+	t.flagged[taskID]++
+}
+
+// NonConforming returns the sorted IDs of tasks whose workers emitted invalid telemetry.
+func (t *TelemetryMonitor) NonConforming() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make([]string, 0, len(t.flagged))
+	for id := range t.flagged {
+		out = append(out, id)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,71 @@
+package exec
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+
+	m "github.com/james/tasks-planner/internal/model"
+	"github.com/james/tasks-planner/internal/telemetry"
+)
+
+func jsonlTask(id string) m.Task {
+	task := m.Task{ID: id}
+	task.ExecutionLogging.Format = "JSONL"
+	task.ExecutionLogging.RequiredFields = telemetry.DefaultRequiredFields
+	return task
+}
+
+func TestCommandRunnerFeedsTelemetry(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires sh")
+	}
+	dir := t.TempDir()
+	var mu sync.Mutex
+	progress := map[string]float64{}
+	var violations []telemetry.Violation
+	monitor := &TelemetryMonitor{
+		Dir: dir,
+		Progress: func(id string, pct float64) {
+			mu.Lock()
+			progress[id] = pct
+			mu.Unlock()
+		},
+		OnViolation: func(task m.Task, v telemetry.Violation) { violations = append(violations, v) },
+	}
+	worker := CommandRunner{
+		Command: `printf '%s\n' ` +
+			`'{"timestamp":"t","task_id":"T1","step":"build","status":"progress","message":"half","progress":50}' ` +
+			`'not json'`,
+		Telemetry: monitor,
+	}
+	if err := worker.Run(context.Background(), jsonlTask("T1")); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if progress["T1"] != 50 {
+		t.Fatalf("expected progress 50, got %v", progress)
+	}
+	if len(violations) != 1 || violations[0].Line != 2 {
+		t.Fatalf("expected one violation on line 2, got %+v", violations)
+	}
+	if got := monitor.NonConforming(); len(got) != 1 || got[0] != "T1" {
+		t.Fatalf("expected T1 flagged, got %v", got)
+	}
+	raw, err := os.ReadFile(filepath.Join(dir, "T1.jsonl"))
+	if err != nil || len(raw) == 0 {
+		t.Fatalf("expected raw telemetry kept: %v", err)
+	}
+}
+
+func TestTelemetryMonitorIgnoresPlainTasks(t *testing.T) {
+	monitor := &TelemetryMonitor{}
+	if monitor.Enabled(m.Task{ID: "T1"}) {
+		t.Fatal("task without JSONL logging should not be monitored")
+	}
+	if !monitor.Enabled(jsonlTask("T1")) {
+		t.Fatal("JSONL task should be monitored")
+	}
+}
@@ -0,0 +1,211 @@
+package exec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	m "github.com/james/tasks-planner/internal/model"
+)
+
+// ErrNoCapableWorker is returned when a task needs capabilities no registered worker offers.
+var ErrNoCapableWorker = errors.New("no worker with required capabilities")
+
+// Worker is an execution backend that advertises capability tags and runs one task at a time.
+type Worker interface {
+	ID() string
+	Capabilities() []string
+	Run(ctx context.Context, task m.Task) error
+}
+
+// WorkerPool routes each task to an idle worker offering every capability in Task.Capabilities.
+// It is a ResourceGate, so a task waits in the frontier until a matching worker is free, and its
+// Run method is the TaskRunner that executes the task on the worker it was admitted to. Among
+// matching workers the one with the fewest extra capabilities is chosen so generalists stay free.
+type WorkerPool struct {
+	mu       sync.Mutex
+	workers  []Worker
+	caps     []map[string]bool
+	busy     map[int]string
+	assigned map[string]int
+	last     map[string]string
+}
+
+// NewWorkerPool registers workers; IDs must be unique.
+func NewWorkerPool(workers ...Worker) (*WorkerPool, error) {
+	p := &WorkerPool{busy: map[int]string{}, assigned: map[string]int{}, last: map[string]string{}}
+	seen := map[string]bool{}
+	for _, w := range workers {
+		if strings.TrimSpace(w.ID()) == "" {
+			return nil, errors.New("worker pool: worker id is required")
+		}
+		if seen[w.ID()] {
+			return nil, fmt.Errorf("worker pool: duplicate worker %s", w.ID())
+		}
+		seen[w.ID()] = true
+		caps := map[string]bool{}
+		for _, c := range w.Capabilities() {
+			caps[normalizeCapability(c)] = true
+		}
+		p.workers = append(p.workers, w)
+		p.caps = append(p.caps, caps)
+	}
+	return p, nil
+}
+
+// Check reports every task no registered worker can ever run.
+func (p *WorkerPool) Check(tasks []m.Task) error {
+	var errs []error
+	for _, t := range tasks {
+		if len(p.matching(t)) == 0 {
+			errs = append(errs, fmt.Errorf("%w: task %s needs [%s]", ErrNoCapableWorker, t.ID, strings.Join(t.Capabilities, ", ")))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (p *WorkerPool) matching(task m.Task) []int {
+	var out []int
+	for i, caps := range p.caps {
+		ok := true
+		for _, c := range task.Capabilities {
+			if !caps[normalizeCapability(c)] {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			out = append(out, i)
+		}
+	}
+	sort.SliceStable(out, func(a, b int) bool { return len(p.caps[out[a]]) < len(p.caps[out[b]]) })
+	return out
+}
+
+// TryAcquire implements ResourceGate by reserving the best idle matching worker.
+func (p *WorkerPool) TryAcquire(task m.Task) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, i := range p.matching(task) {
+		if _, busy := p.busy[i]; busy {
+			continue
+		}
+		p.busy[i] = task.ID
+		p.assigned[task.ID] = i
+		p.last[task.ID] = p.workers[i].ID()
+		return true
+	}
+	return false
+}
+
+// Release implements ResourceGate.
+func (p *WorkerPool) Release(task m.Task) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if i, ok := p.assigned[task.ID]; ok {
+		delete(p.busy, i)
+		delete(p.assigned, task.ID)
+	}
+}
+
+// Run executes task on the worker it was admitted to.
+func (p *WorkerPool) Run(ctx context.Context, task m.Task) error {
+	p.mu.Lock()
+	i, ok := p.assigned[task.ID]
+	p.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("worker pool: task %s was not admitted to a worker", task.ID)
+	}
+	return p.workers[i].Run(ctx, task)
+}
+
+// Assigned returns the worker that most recently took task (empty if none has).
+func (p *WorkerPool) Assigned(taskID string) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.last[taskID]
+}
+
+func normalizeCapability(c string) string {
+	return strings.ToLower(strings.TrimSpace(c))
+}
+
+// LocalWorker runs tasks as local processes through a CommandRunner. The worker ID is exported
+// to the command as TASKS_WORKER_ID.
+type LocalWorker struct {
+	Name   string
+	Caps   []string
+	Runner CommandRunner
+}
+
+// ID implements Worker.
+func (w LocalWorker) ID() string { return w.Name }
+
+// Capabilities implements Worker.
+func (w LocalWorker) Capabilities() []string { return w.Caps }
+
+// Run implements Worker.
+func (w LocalWorker) Run(ctx context.Context, task m.Task) error {
+	r := w.Runner
+	r.Env = append(append([]string(nil), r.Env...), "TASKS_WORKER_ID="+w.Name)
+	return r.Run(ctx, task)
+}
+
+// MemoryWorker is an in-process backend for tests: it records the tasks it ran and delegates to
+// Fn (success when nil).
+type MemoryWorker struct {
+	Name string
+	Caps []string
+	Fn   func(ctx context.Context, task m.Task) error
+
+	mu  sync.Mutex
+	ran []string
+}
+
+// ID implements Worker.
+func (w *MemoryWorker) ID() string { return w.Name }
+
+// Capabilities implements Worker.
+func (w *MemoryWorker) Capabilities() []string { return w.Caps }
+
+// Run implements Worker.
+func (w *MemoryWorker) Run(ctx context.Context, task m.Task) error {
+	w.mu.Lock()
+	w.ran = append(w.ran, task.ID)
+	w.mu.Unlock()
+	if w.Fn == nil {
+		return nil
+	}
+	return w.Fn(ctx, task)
+}
+
+// Ran returns the IDs of the tasks this worker ran, in order.
+func (w *MemoryWorker) Ran() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]string(nil), w.ran...)
+}
+
+// WorkerSpec declares a local worker and the capabilities it offers.
+type WorkerSpec struct {
+	ID           string
+	Capabilities []string
+}
+
+// ParseWorkerSpec parses "id=cap1,cap2" (capabilities optional) as used by slapsd --worker.
+func ParseWorkerSpec(spec string) (WorkerSpec, error) {
+	id, rest, _ := strings.Cut(spec, "=")
+	ws := WorkerSpec{ID: strings.TrimSpace(id)}
+	if ws.ID == "" {
+		return WorkerSpec{}, fmt.Errorf("worker spec %q: id is required", spec)
+	}
+	for _, c := range strings.Split(rest, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			ws.Capabilities = append(ws.Capabilities, c)
+		}
+	}
+	return ws, nil
+}
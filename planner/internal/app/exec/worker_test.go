@@ -0,0 +1,117 @@
+package exec
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	m "github.com/james/tasks-planner/internal/model"
+)
+
+func TestWorkerPoolRoutesByCapability(t *testing.T) {
+	coord := chainCoordinator([]string{"API", "UI", "DOCS"}, nil)
+	coord.Graph.Nodes[0].Capabilities = []string{"go"}
+	coord.Graph.Nodes[1].Capabilities = []string{"Frontend"}
+	gobuild := &MemoryWorker{Name: "go-1", Caps: []string{"go"}}
+	web := &MemoryWorker{Name: "web-1", Caps: []string{"frontend", "node"}}
+	pool, err := NewWorkerPool(gobuild, web)
+	if err != nil {
+		t.Fatalf("pool: %v", err)
+	}
+	var mu sync.Mutex
+	workerOf := map[string]string{}
+	rt := &Runtime{
+		Runner:  pool.Run,
+		Workers: pool,
+		Notify: func(ev Event) {
+			mu.Lock()
+			defer mu.Unlock()
+			if ev.State == TaskRunning {
+				workerOf[ev.TaskID] = ev.WorkerID
+			}
+		},
+	}
+	if err := rt.Init(context.Background(), coord); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	if err := rt.Run(context.Background()); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if workerOf["API"] != "go-1" || workerOf["UI"] != "web-1" || workerOf["DOCS"] == "" {
+		t.Fatalf("unexpected routing %v", workerOf)
+	}
+	if n := len(gobuild.Ran()) + len(web.Ran()); n != 3 {
+		t.Fatalf("expected 3 runs, got %d", n)
+	}
+}
+
+func TestWorkerPoolHoldsWorkerUntilRelease(t *testing.T) {
+	pool, err := NewWorkerPool(&MemoryWorker{Name: "a", Caps: []string{"go"}}, &MemoryWorker{Name: "b", Caps: []string{"go", "docker"}})
+	if err != nil {
+		t.Fatalf("pool: %v", err)
+	}
+	t1 := m.Task{ID: "T1", Capabilities: []string{"go"}}
+	t2 := m.Task{ID: "T2", Capabilities: []string{"go"}}
+	t3 := m.Task{ID: "T3", Capabilities: []string{"docker"}}
+	if !pool.TryAcquire(t1) || pool.Assigned("T1") != "a" {
+		t.Fatalf("expected the specialist worker first, got %q", pool.Assigned("T1"))
+	}
+	if !pool.TryAcquire(t2) || pool.Assigned("T2") != "b" {
+		t.Fatalf("expected the generalist next, got %q", pool.Assigned("T2"))
+	}
+	if pool.TryAcquire(t3) {
+		t.Fatal("docker task should wait for b")
+	}
+	pool.Release(t2)
+	if !pool.TryAcquire(t3) {
+		t.Fatal("docker task should take b once released")
+	}
+}
+
+func TestWorkerPoolRejectsUnroutableTasks(t *testing.T) {
+	pool, err := NewWorkerPool(&MemoryWorker{Name: "go-1", Caps: []string{"go"}})
+	if err != nil {
+		t.Fatalf("pool: %v", err)
+	}
+	coord := chainCoordinator([]string{"UI"}, nil)
+	coord.Graph.Nodes[0].Capabilities = []string{"frontend"}
+	rt := &Runtime{Runner: pool.Run, Workers: pool}
+	err = rt.Init(context.Background(), coord)
+	if !errors.Is(err, ErrNoCapableWorker) || !strings.Contains(err.Error(), "UI") {
+		t.Fatalf("expected ErrNoCapableWorker for UI, got %v", err)
+	}
+	if _, err := NewWorkerPool(&MemoryWorker{Name: "x"}, &MemoryWorker{Name: "x"}); err == nil {
+		t.Fatal("expected duplicate worker ids to be rejected")
+	}
+}
+
+func TestParseWorkerSpec(t *testing.T) {
+	ws, err := ParseWorkerSpec("web-1= frontend, node ")
+	if err != nil || ws.ID != "web-1" || len(ws.Capabilities) != 2 || ws.Capabilities[1] != "node" {
+		t.Fatalf("unexpected spec %+v, %v", ws, err)
+	}
+	if _, err := ParseWorkerSpec("=go"); err == nil {
+		t.Fatal("expected missing id to be rejected")
+	}
+}
+
+func TestLocalWorkerExportsWorkerID(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires sh")
+	}
+	var out bytes.Buffer
+	w := LocalWorker{Name: "go-1", Caps: []string{"go"}, Runner: CommandRunner{
+		Command: `printf '%s/%s' "$TASKS_WORKER_ID" "$TASKS_TASK_ID"`,
+		Stdout:  &out,
+	}}
+	if err := w.Run(context.Background(), m.Task{ID: "T001"}); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if out.String() != "go-1/T001" {
+		t.Fatalf("unexpected output %q", out.String())
+	}
+}
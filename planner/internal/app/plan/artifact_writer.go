@@ -78,6 +78,16 @@ func (FileArtifactWriter) Write(ctx context.Context, out string, bundle Artifact
 	return ArtifactWriteResult{Hashes: hashes}, nil
 }
 
+// WriteFailedDAG writes dag.json for a graph that failed to build so its analysis can be inspected.
+// No other artifact is written.
+func (FileArtifactWriter) WriteFailedDAG(ctx context.Context, out string, df *m.DagFile) error {
+	df.Meta.ArtifactHash = ""
+	if _, err := emitter.WriteWithArtifactHash(filepath.Join(out, "dag.json"), df, func(h string) { df.Meta.ArtifactHash = h }); err != nil {
+		return fmt.Errorf("dag.json: %w", err)
+	}
+	return nil
+}
+
 type artifactErrors []error
 
 func (a artifactErrors) Error() string {
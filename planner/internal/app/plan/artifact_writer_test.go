@@ -2,6 +2,7 @@ package plan
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"os"
 	"path/filepath"
@@ -92,3 +93,29 @@ func TestFileArtifactWriterAggregatesErrors(t *testing.T) {
 		t.Fatalf("expected aggregated error, got %T", err)
 	}
 }
+
+func TestFileArtifactWriterWritesFailedDAG(t *testing.T) {
+	tmp := t.TempDir()
+	df := &m.DagFile{}
+	df.Meta.Version = "v8"
+	df.Analysis.Errors = []string{"cycle detected in dependencies: A -> B -> A (2 tasks in component)"}
+	df.Analysis.Cycles = []m.CycleDiagnostic{{Tasks: []string{"A", "B"}, Cycle: []string{"A", "B", "A"}}}
+
+	if err := (FileArtifactWriter{}).WriteFailedDAG(context.Background(), tmp, df); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	raw, err := os.ReadFile(filepath.Join(tmp, "dag.json"))
+	if err != nil {
+		t.Fatalf("read dag.json: %v", err)
+	}
+	var got m.DagFile
+	if err := json.Unmarshal(raw, &got); err != nil {
+		t.Fatalf("parse dag.json: %v", err)
+	}
+	if len(got.Analysis.Cycles) != 1 || got.Meta.ArtifactHash == "" || got.Meta.ArtifactHash != df.Meta.ArtifactHash {
+		t.Fatalf("dag.json = %s", raw)
+	}
+	if _, err := os.Stat(filepath.Join(tmp, "tasks.json")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("tasks.json should not be written, stat err = %v", err)
+	}
+}
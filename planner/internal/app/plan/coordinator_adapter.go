@@ -18,5 +18,8 @@ func (DefaultCoordinatorBuilder) Build(tasks []m.Task, deps []m.Edge) m.Coordina
 	coord.Config.Resources.Catalog = map[string]m.ResourceSpec{}
 	coord.Config.Resources.Profiles = map[string]map[string]int{"default": {}}
 	coord.Config.Policies.LockOrdering = []string{}
+	coord.Config.Policies.CircuitBreakers = []m.CircuitBreaker{}
+	coord.Config.Policies.Retry = m.RetryPolicy{MaxAttempts: 1}
+	coord.Config.Policies.Priority = m.DefaultPriorityWeights()
 	return coord
 }
@@ -86,6 +86,14 @@ func (l MarkdownDocLoader) Load(ctx context.Context, docPath string) (TasksResul
 		if len(spec.Accept) > 0 {
 			task.AcceptanceChecks = append(task.AcceptanceChecks, spec.Accept...)
 		}
+		task.Compensation.RollbackCmd = spec.Rollback
+		task.Capabilities = spec.Caps
+		for _, name := range spec.Produces {
+			task.InterfacesProduced = append(task.InterfacesProduced, m.InterfaceProduced{Name: name})
+		}
+		for _, name := range spec.Consumes {
+			task.InterfacesConsumed = append(task.InterfacesConsumed, m.InterfaceConsumed{Name: name, Required: true})
+		}
 		applyTaskDefaults(&task)
 		tasks = append(tasks, task)
 		key := normalizeKey(spec.Title)
@@ -176,7 +184,8 @@ func applyTaskDefaults(task *m.Task) {
 	if len(task.ExecutionLogging.RequiredFields) == 0 {
 		task.ExecutionLogging.RequiredFields = []string{"timestamp", "task_id", "step", "status", "message"}
 	}
-	task.Compensation.Idempotent = true
+	// A declared rollback means re-running is unsafe; everything else is assumed idempotent.
+	task.Compensation.Idempotent = task.Compensation.RollbackCmd == ""
 }
 
 func resolveTaskID(token string, titleToID map[string]string) string {
@@ -135,6 +135,123 @@ func TestApplyTaskDefaults(t *testing.T) {
 	}
 }
 
+func TestMarkdownDocLoaderParsesRollback(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plan.md")
+	content := strings.Join([]string{
+		"## Data",
+		"- Migrate schema (2h)",
+		"  rollback: ./scripts/migrate down",
+		"- Seed fixtures after: Migrate schema",
+	}, "\n")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	res, err := NewMarkdownDocLoader().Load(context.Background(), path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(res.Tasks) != 2 {
+		t.Fatalf("expected 2 tasks, got %+v", res.Tasks)
+	}
+	migrate, seed := res.Tasks[0], res.Tasks[1]
+	if migrate.Compensation.RollbackCmd != "./scripts/migrate down" || migrate.Compensation.Idempotent {
+		t.Fatalf("expected non-idempotent migrate with rollback, got %+v", migrate.Compensation)
+	}
+	if seed.Compensation.RollbackCmd != "" || !seed.Compensation.Idempotent {
+		t.Fatalf("expected seed to stay idempotent, got %+v", seed.Compensation)
+	}
+}
+
+func TestMarkdownDocLoaderParsesCapabilities(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plan.md")
+	content := strings.Join([]string{
+		"## Build",
+		"- Compile API (1h)",
+		"  capabilities: Go, docker",
+		"- Bundle UI",
+		"  caps: frontend",
+		"- Write notes",
+	}, "\n")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	res, err := NewMarkdownDocLoader().Load(context.Background(), path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(res.Tasks) != 3 {
+		t.Fatalf("expected 3 tasks, got %+v", res.Tasks)
+	}
+	if got := res.Tasks[0].Capabilities; len(got) != 2 || got[0] != "go" || got[1] != "docker" {
+		t.Fatalf("unexpected API capabilities %v", got)
+	}
+	if got := res.Tasks[1].Capabilities; len(got) != 1 || got[0] != "frontend" {
+		t.Fatalf("unexpected UI capabilities %v", got)
+	}
+	if got := res.Tasks[2].Capabilities; len(got) != 0 {
+		t.Fatalf("expected no capabilities, got %v", got)
+	}
+}
+
+func TestMarkdownDocLoaderParsesInterfaces(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plan.md")
+	content := strings.Join([]string{
+		"## API",
+		"- Build users API (2h)",
+		"  produces: users-api, users-events",
+		"- Build dashboard after: Build users API",
+		"  consumes: users-api",
+	}, "\n")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	res, err := NewMarkdownDocLoader().Load(context.Background(), path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if got := res.Tasks[0].InterfacesProduced; len(got) != 2 || got[0].Name != "users-api" || got[1].Name != "users-events" {
+		t.Fatalf("unexpected produced interfaces %+v", got)
+	}
+	if got := res.Tasks[1].InterfacesConsumed; len(got) != 1 || got[0].Name != "users-api" || !got[0].Required {
+		t.Fatalf("unexpected consumed interfaces %+v", got)
+	}
+}
+
+func TestMarkdownDocLoaderKeepsAttributeKeywordTitles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plan.md")
+	content := strings.Join([]string{
+		"## Data",
+		"- Rollback: old schema (1h)",
+		"  - rollback: ./scripts/restore",
+		"- Produces: nightly report",
+		"* Capabilities: audit",
+		"  caps: go",
+	}, "\n")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	res, err := NewMarkdownDocLoader().Load(context.Background(), path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	var titles []string
+	for _, task := range res.Tasks {
+		titles = append(titles, task.Title)
+	}
+	if want := []string{"Rollback: old schema", "Produces: nightly report", "Capabilities: audit"}; !reflect.DeepEqual(titles, want) {
+		t.Fatalf("titles = %v, want %v", titles, want)
+	}
+	if got := res.Tasks[0].Compensation.RollbackCmd; got != "./scripts/restore" {
+		t.Fatalf("expected the indented rollback attached to the first task, got %q", got)
+	}
+	if got := res.Tasks[1].InterfacesProduced; len(got) != 0 {
+		t.Fatalf("expected no interfaces, got %+v", got)
+	}
+	if got := res.Tasks[2].Capabilities; len(got) != 1 || got[0] != "go" {
+		t.Fatalf("unexpected capabilities %v", got)
+	}
+}
+
 func TestResolveTaskIDAllowsLongerIDs(t *testing.T) {
 	got := resolveTaskID("T12345", map[string]string{"task": "T001"})
 	if got != "T12345" {
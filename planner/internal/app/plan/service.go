@@ -4,10 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"path/filepath"
 	"sort"
 	"strings"
 
 	analysis "github.com/james/tasks-planner/internal/analysis"
+	"github.com/james/tasks-planner/internal/forecast"
 	m "github.com/james/tasks-planner/internal/model"
 	"github.com/james/tasks-planner/internal/validators"
 )
@@ -17,6 +19,12 @@ const (
 	schemaVersion        = "v8"
 )
 
+// Quality gate modes for Request.QualityGates.
+const (
+	QualityGatesWarn   = "warn"
+	QualityGatesStrict = "strict"
+)
+
 // FeatureSummary represents a lightweight feature descriptor produced by the spec loader.
 type FeatureSummary struct {
 	ID    string
@@ -58,12 +66,16 @@ type Service struct {
 	BuildTasks         func(ctx context.Context, docPath string) (TasksResult, error)
 	AnalyzeRepo        func(ctx context.Context, repo string) (analysis.FileCensusCounts, error)
 	ResolveDeps        func(tasks []m.Task, docEdges []m.Edge) ([]m.Edge, map[string]any)
+	RepairCycles       func(tasks []m.Task, deps []m.Edge, minConfidence float64) ([]m.Task, []m.Edge, []m.CycleRepair)
 	BuildDAG           func(ctx context.Context, tasks []m.Task, deps []m.Edge, minConfidence float64) (*m.DagFile, error)
 	BuildCoordinator   func(tasks []m.Task, deps []m.Edge) m.Coordinator
 	ValidateTasks      func(tf *m.TasksFile) error
 	ValidateDAG        func(df *m.DagFile) error
+	CheckQuality       func(df *m.DagFile) []string
 	BuildWaves         func(ctx context.Context, df *m.DagFile, tasks []m.Task) (*m.WavesArtifact, error)
+	Forecast           func(ctx context.Context, coord m.Coordinator, waves [][]string, opts forecast.Options) (forecast.Result, error)
 	WriteArtifacts     func(ctx context.Context, out string, bundle ArtifactBundle) (ArtifactWriteResult, error)
+	WriteFailedDAG     func(ctx context.Context, out string, df *m.DagFile) error
 	NewValidatorRunner func(cfg validators.Config) (ValidatorRunner, error)
 }
 
@@ -75,6 +87,16 @@ type Request struct {
 	MinConfidence    *float64
 	ValidatorConfig  validators.Config
 	StrictValidators bool
+	// QualityGates is QualityGatesWarn (the default when empty) or QualityGatesStrict, which
+	// fails the plan when DAG quality metrics fall outside their bands.
+	QualityGates string
+	// RepairCycles lets the planner break cycles through interface hand-offs by inserting
+	// contract tasks before the DAG is built.
+	RepairCycles bool
+	// ForecastRuns and ForecastSeed drive the Monte Carlo estimates (zero runs selects
+	// forecast.DefaultRuns).
+	ForecastRuns int
+	ForecastSeed int64
 }
 
 // Result summarizes planner execution output.
@@ -82,6 +104,8 @@ type Result struct {
 	ArtifactHashes   map[string]string
 	ValidatorReports []m.ValidatorReport
 	Warnings         []string
+	QualityWarnings  []string
+	CycleRepairs     []m.CycleRepair
 }
 
 // Plan executes the planning workflow.
@@ -90,6 +114,12 @@ func (s Service) Plan(ctx context.Context, req Request) (Result, error) {
 		return Result{}, errors.New("plan service: missing required adapters")
 	}
 
+	switch req.QualityGates {
+	case "", QualityGatesWarn, QualityGatesStrict:
+	default:
+		return Result{}, fmt.Errorf("quality gates: unknown mode %q (want %s or %s)", req.QualityGates, QualityGatesWarn, QualityGatesStrict)
+	}
+
 	tasksRes, err := s.BuildTasks(ctx, req.DocPath)
 	if err != nil {
 		return Result{}, fmt.Errorf("load tasks: %w", err)
@@ -134,17 +164,37 @@ func (s Service) Plan(ctx context.Context, req Request) (Result, error) {
 		}
 	}
 
+	if req.RepairCycles && s.RepairCycles != nil {
+		tasks, deps, repairs := s.RepairCycles(tf.Tasks, tf.Dependencies, tf.Meta.MinConfidence)
+		tf.Tasks, tf.Dependencies = tasks, deps
+		tf.Meta.Autonormalization.CycleRepairs = repairs
+	}
+
 	if err := s.ValidateTasks(tf); err != nil {
 		return Result{}, fmt.Errorf("validate tasks: %w", err)
 	}
 
 	dagFile, err := s.BuildDAG(ctx, tf.Tasks, tf.Dependencies, tf.Meta.MinConfidence)
 	if err != nil {
+		// Keep the diagnostics (cycles, break candidates) inspectable even though planning stops.
+		if dagFile != nil && s.WriteFailedDAG != nil {
+			if werr := s.WriteFailedDAG(ctx, req.OutDir, dagFile); werr != nil {
+				return Result{}, fmt.Errorf("build dag: %w (writing diagnostics: %v)", err, werr)
+			}
+			return Result{}, fmt.Errorf("build dag: %w (diagnostics in %s)", err, filepath.Join(req.OutDir, "dag.json"))
+		}
 		return Result{}, fmt.Errorf("build dag: %w", err)
 	}
 	if err := s.ValidateDAG(dagFile); err != nil {
 		return Result{}, fmt.Errorf("validate dag: %w", err)
 	}
+	var qualityWarnings []string
+	if s.CheckQuality != nil {
+		qualityWarnings = s.CheckQuality(dagFile)
+		if req.QualityGates == QualityGatesStrict && len(qualityWarnings) > 0 {
+			return Result{}, fmt.Errorf("quality gates failed: %s", strings.Join(qualityWarnings, "; "))
+		}
+	}
 
 	waves, err := s.BuildWaves(ctx, dagFile, tf.Tasks)
 	if err != nil {
@@ -164,6 +214,18 @@ func (s Service) Plan(ctx context.Context, req Request) (Result, error) {
 	} else {
 		coord = makeCoordinator(tf.Tasks, tf.Dependencies)
 	}
+	coord.Metrics.Estimates.LongestPathLength = dagFile.Metrics.LongestPathLength
+	coord.Metrics.Estimates.WidthApprox = dagFile.Metrics.WidthApprox
+	if s.Forecast != nil && waves != nil {
+		fc, err := s.Forecast(ctx, coord, waves.Waves, forecast.Options{Runs: req.ForecastRuns, Seed: req.ForecastSeed})
+		if err != nil {
+			return Result{}, fmt.Errorf("estimate schedule: %w", err)
+		}
+		coord.Metrics.Estimates.P50TotalHours = fc.Total.P50
+		coord.Metrics.Estimates.P80TotalHours = fc.Total.P80
+		coord.Metrics.Estimates.P95TotalHours = fc.Total.P95
+		waves.Estimates = fc.Waves
+	}
 
 	var validatorReports []m.ValidatorReport
 	var warnings []string
@@ -231,6 +293,8 @@ func (s Service) Plan(ctx context.Context, req Request) (Result, error) {
 		ArtifactHashes:   writeResult.Hashes,
 		ValidatorReports: tf.Meta.ValidatorReports,
 		Warnings:         warnings,
+		QualityWarnings:  qualityWarnings,
+		CycleRepairs:     tf.Meta.Autonormalization.CycleRepairs,
 	}
 	return result, nil
 }
@@ -268,14 +332,7 @@ func makeFeaturesArtifact(features []FeatureSummary) *m.FeaturesArtifact {
 }
 
 func makeCoordinator(tasks []m.Task, deps []m.Edge) m.Coordinator {
-	coord := m.Coordinator{}
-	coord.Version = schemaVersion
-	coord.Graph.Nodes = tasks
-	coord.Graph.Edges = deps
-	coord.Config.Resources.Catalog = map[string]m.ResourceSpec{}
-	coord.Config.Resources.Profiles = map[string]map[string]int{"default": {}}
-	coord.Config.Policies.LockOrdering = []string{}
-	return coord
+	return DefaultCoordinatorBuilder{}.Build(tasks, deps)
 }
 
 func convertValidatorReports(src []validators.Report) []m.ValidatorReport {
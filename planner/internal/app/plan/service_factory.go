@@ -3,6 +3,7 @@ package plan
 import (
 	"context"
 
+	"github.com/james/tasks-planner/internal/forecast"
 	m "github.com/james/tasks-planner/internal/model"
 	dagbuild "github.com/james/tasks-planner/internal/planner/dag"
 	"github.com/james/tasks-planner/internal/validate"
@@ -22,14 +23,22 @@ func NewDefaultService() Service {
 		BuildTasks:  docLoader.Load,
 		AnalyzeRepo: analyzer.Analyze,
 		ResolveDeps: deps.Resolve,
+		RepairCycles: func(tasks []m.Task, edges []m.Edge, minConfidence float64) ([]m.Task, []m.Edge, []m.CycleRepair) {
+			return dagbuild.RepairCycles(tasks, edges, minConfidence, dagbuild.MaxRepairPasses)
+		},
 		BuildDAG: func(_ context.Context, tasks []m.Task, edges []m.Edge, minConfidence float64) (*m.DagFile, error) {
 			return dagbuild.Build(tasks, edges, minConfidence)
 		},
 		BuildCoordinator: coord.Build,
 		ValidateTasks:    validate.TasksFile,
 		ValidateDAG:      validate.DagFile,
+		CheckQuality:     dagbuild.QualityWarnings,
 		BuildWaves:       waves.Build,
-		WriteArtifacts:   artifacts.Write,
+		Forecast: func(_ context.Context, c m.Coordinator, waves [][]string, opts forecast.Options) (forecast.Result, error) {
+			return forecast.Run(c, waves, opts)
+		},
+		WriteArtifacts: artifacts.Write,
+		WriteFailedDAG: artifacts.WriteFailedDAG,
 		NewValidatorRunner: func(cfg validators.Config) (ValidatorRunner, error) {
 			return validators.NewRunner(cfg)
 		},
@@ -13,6 +13,9 @@ func TestNewDefaultServiceProvidesAdapters(t *testing.T) {
 	if svc.ResolveDeps == nil {
 		t.Error("ResolveDeps adapter is nil")
 	}
+	if svc.RepairCycles == nil {
+		t.Error("RepairCycles adapter is nil")
+	}
 	if svc.BuildDAG == nil {
 		t.Error("BuildDAG adapter is nil")
 	}
@@ -25,12 +28,21 @@ func TestNewDefaultServiceProvidesAdapters(t *testing.T) {
 	if svc.ValidateDAG == nil {
 		t.Error("ValidateDAG adapter is nil")
 	}
+	if svc.CheckQuality == nil {
+		t.Error("CheckQuality adapter is nil")
+	}
 	if svc.BuildWaves == nil {
 		t.Error("BuildWaves adapter is nil")
 	}
+	if svc.Forecast == nil {
+		t.Error("Forecast adapter is nil")
+	}
 	if svc.WriteArtifacts == nil {
 		t.Error("WriteArtifacts adapter is nil")
 	}
+	if svc.WriteFailedDAG == nil {
+		t.Error("WriteFailedDAG adapter is nil")
+	}
 	if svc.NewValidatorRunner == nil {
 		t.Error("NewValidatorRunner adapter is nil")
 	}
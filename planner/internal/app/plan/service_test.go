@@ -8,6 +8,7 @@ import (
 
 	analysis "github.com/james/tasks-planner/internal/analysis"
 	"github.com/james/tasks-planner/internal/app/plan"
+	"github.com/james/tasks-planner/internal/forecast"
 	m "github.com/james/tasks-planner/internal/model"
 	"github.com/james/tasks-planner/internal/validators"
 )
@@ -224,3 +225,182 @@ func TestServicePlanValidatorStrictStatusFailure(t *testing.T) {
 		t.Fatalf("expected error for failing validator status")
 	}
 }
+
+func TestServicePlanForecastEstimates(t *testing.T) {
+	var bundle plan.ArtifactBundle
+	svc := plan.Service{
+		BuildTasks: func(context.Context, string) (plan.TasksResult, error) {
+			return plan.TasksResult{Tasks: []m.Task{{ID: "T001", Title: "Do"}}}, nil
+		},
+		AnalyzeRepo: func(context.Context, string) (analysis.FileCensusCounts, error) {
+			return analysis.FileCensusCounts{}, nil
+		},
+		BuildDAG: func(context.Context, []m.Task, []m.Edge, float64) (*m.DagFile, error) {
+			df := &m.DagFile{}
+			df.Metrics.LongestPathLength = 1
+			df.Metrics.WidthApprox = 1
+			return df, nil
+		},
+		ValidateTasks: func(*m.TasksFile) error { return nil },
+		ValidateDAG:   func(*m.DagFile) error { return nil },
+		BuildWaves: func(context.Context, *m.DagFile, []m.Task) (*m.WavesArtifact, error) {
+			return &m.WavesArtifact{Waves: [][]string{{"T001"}}}, nil
+		},
+		Forecast: func(_ context.Context, coord m.Coordinator, waves [][]string, opts forecast.Options) (forecast.Result, error) {
+			if opts.Runs != 50 || opts.Seed != 9 || len(waves) != 1 || len(coord.Graph.Nodes) != 1 {
+				t.Fatalf("unexpected forecast input: %+v %v", opts, waves)
+			}
+			return forecast.Result{
+				Total: forecast.Percentiles{P50: 2, P80: 3, P95: 4},
+				Waves: []m.WaveEstimate{{Wave: 0, P50Hours: 2, P80Hours: 3, P95Hours: 4}},
+			}, nil
+		},
+		WriteArtifacts: func(_ context.Context, _ string, b plan.ArtifactBundle) (plan.ArtifactWriteResult, error) {
+			bundle = b
+			return plan.ArtifactWriteResult{}, nil
+		},
+	}
+
+	if _, err := svc.Plan(context.Background(), plan.Request{OutDir: "./plans", ForecastRuns: 50, ForecastSeed: 9}); err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+	est := bundle.Coordinator.Metrics.Estimates
+	if est.P50TotalHours != 2 || est.P80TotalHours != 3 || est.P95TotalHours != 4 || est.LongestPathLength != 1 || est.WidthApprox != 1 {
+		t.Fatalf("unexpected coordinator estimates: %+v", est)
+	}
+	if len(bundle.Waves.Estimates) != 1 || bundle.Waves.Estimates[0].P95Hours != 4 {
+		t.Fatalf("unexpected wave estimates: %+v", bundle.Waves.Estimates)
+	}
+}
+
+func TestServicePlanQualityGates(t *testing.T) {
+	wrote := false
+	svc := plan.Service{
+		BuildTasks: func(context.Context, string) (plan.TasksResult, error) {
+			return plan.TasksResult{Tasks: []m.Task{{ID: "T001", Title: "Do"}}}, nil
+		},
+		AnalyzeRepo: func(context.Context, string) (analysis.FileCensusCounts, error) {
+			return analysis.FileCensusCounts{}, nil
+		},
+		BuildDAG:      func(context.Context, []m.Task, []m.Edge, float64) (*m.DagFile, error) { return &m.DagFile{}, nil },
+		ValidateTasks: func(*m.TasksFile) error { return nil },
+		ValidateDAG:   func(*m.DagFile) error { return nil },
+		CheckQuality:  func(*m.DagFile) []string { return []string{"evidence coverage 0.0% below 95%"} },
+		BuildWaves: func(context.Context, *m.DagFile, []m.Task) (*m.WavesArtifact, error) {
+			return &m.WavesArtifact{}, nil
+		},
+		WriteArtifacts: func(context.Context, string, plan.ArtifactBundle) (plan.ArtifactWriteResult, error) {
+			wrote = true
+			return plan.ArtifactWriteResult{}, nil
+		},
+	}
+
+	res, err := svc.Plan(context.Background(), plan.Request{OutDir: "./plans"})
+	if err != nil {
+		t.Fatalf("plan (warn): %v", err)
+	}
+	if len(res.QualityWarnings) != 1 || !wrote {
+		t.Fatalf("expected quality warning and artifacts, got %+v (wrote %v)", res.QualityWarnings, wrote)
+	}
+
+	wrote = false
+	_, err = svc.Plan(context.Background(), plan.Request{OutDir: "./plans", QualityGates: plan.QualityGatesStrict})
+	if err == nil || !strings.Contains(err.Error(), "quality gates failed: evidence coverage") {
+		t.Fatalf("plan (strict): err = %v", err)
+	}
+	if wrote {
+		t.Fatal("strict quality gate failure still wrote artifacts")
+	}
+
+	if _, err := svc.Plan(context.Background(), plan.Request{QualityGates: "lenient"}); err == nil {
+		t.Fatal("expected error for unknown quality gate mode")
+	}
+}
+
+func TestServicePlanWritesDiagnosticsOnDAGFailure(t *testing.T) {
+	var written *m.DagFile
+	svc := plan.Service{
+		BuildTasks: func(context.Context, string) (plan.TasksResult, error) {
+			return plan.TasksResult{Tasks: []m.Task{{ID: "T001", Title: "Do"}}}, nil
+		},
+		AnalyzeRepo: func(context.Context, string) (analysis.FileCensusCounts, error) {
+			return analysis.FileCensusCounts{}, nil
+		},
+		BuildDAG: func(context.Context, []m.Task, []m.Edge, float64) (*m.DagFile, error) {
+			df := &m.DagFile{}
+			df.Analysis.Errors = []string{"cycle detected in dependencies"}
+			return df, errors.New("cycle detected")
+		},
+		ValidateTasks: func(*m.TasksFile) error { return nil },
+		ValidateDAG:   func(*m.DagFile) error { return nil },
+		BuildWaves: func(context.Context, *m.DagFile, []m.Task) (*m.WavesArtifact, error) {
+			return &m.WavesArtifact{}, nil
+		},
+		WriteArtifacts: func(context.Context, string, plan.ArtifactBundle) (plan.ArtifactWriteResult, error) {
+			t.Fatal("artifacts must not be written when the DAG fails")
+			return plan.ArtifactWriteResult{}, nil
+		},
+		WriteFailedDAG: func(_ context.Context, out string, df *m.DagFile) error {
+			written = df
+			return nil
+		},
+	}
+
+	_, err := svc.Plan(context.Background(), plan.Request{OutDir: "plans"})
+	if err == nil || !strings.Contains(err.Error(), "diagnostics in plans/dag.json") {
+		t.Fatalf("err = %v", err)
+	}
+	if written == nil || len(written.Analysis.Errors) != 1 {
+		t.Fatalf("diagnostics not written: %+v", written)
+	}
+}
+
+func TestServicePlanRepairsCyclesWhenRequested(t *testing.T) {
+	var bundle plan.ArtifactBundle
+	var built []m.Task
+	svc := plan.Service{
+		BuildTasks: func(context.Context, string) (plan.TasksResult, error) {
+			return plan.TasksResult{
+				Tasks:        []m.Task{{ID: "T001", Title: "Do"}, {ID: "T002", Title: "Use"}},
+				Dependencies: []m.Edge{{From: "T001", To: "T002"}, {From: "T002", To: "T001"}},
+			}, nil
+		},
+		AnalyzeRepo: func(context.Context, string) (analysis.FileCensusCounts, error) {
+			return analysis.FileCensusCounts{}, nil
+		},
+		RepairCycles: func(tasks []m.Task, deps []m.Edge, minConfidence float64) ([]m.Task, []m.Edge, []m.CycleRepair) {
+			contract := m.Task{ID: "C001", Title: "Define api contract"}
+			return append(tasks, contract), deps[1:], []m.CycleRepair{{Pass: 1, Contract: "C001", Interface: "api", Producer: "T001", Consumers: []string{"T002"}}}
+		},
+		BuildDAG: func(_ context.Context, tasks []m.Task, _ []m.Edge, _ float64) (*m.DagFile, error) {
+			built = tasks
+			return &m.DagFile{}, nil
+		},
+		ValidateTasks: func(*m.TasksFile) error { return nil },
+		ValidateDAG:   func(*m.DagFile) error { return nil },
+		BuildWaves: func(context.Context, *m.DagFile, []m.Task) (*m.WavesArtifact, error) {
+			return &m.WavesArtifact{}, nil
+		},
+		WriteArtifacts: func(_ context.Context, _ string, b plan.ArtifactBundle) (plan.ArtifactWriteResult, error) {
+			bundle = b
+			return plan.ArtifactWriteResult{}, nil
+		},
+	}
+
+	if _, err := svc.Plan(context.Background(), plan.Request{OutDir: "./plans"}); err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+	if len(built) != 2 || len(bundle.TasksFile.Meta.Autonormalization.CycleRepairs) != 0 {
+		t.Fatalf("repair ran without being requested: %d tasks", len(built))
+	}
+
+	if _, err := svc.Plan(context.Background(), plan.Request{OutDir: "./plans", RepairCycles: true}); err != nil {
+		t.Fatalf("plan (repair): %v", err)
+	}
+	if len(built) != 3 || len(bundle.TasksFile.Dependencies) != 1 || bundle.Titles.Titles["C001"] == "" {
+		t.Fatalf("repaired tasks not used: %d tasks, deps %+v", len(built), bundle.TasksFile.Dependencies)
+	}
+	if repairs := bundle.TasksFile.Meta.Autonormalization.CycleRepairs; len(repairs) != 1 || repairs[0].Contract != "C001" {
+		t.Fatalf("repairs not recorded: %+v", repairs)
+	}
+}
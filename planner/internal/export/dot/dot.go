@@ -3,6 +3,7 @@ package dot
 import (
     "fmt"
     "sort"
+    "strconv"
     "strings"
 
     m "github.com/james/tasks-planner/internal/model"
@@ -52,8 +53,10 @@ func FromDagWithOptions(d m.DagFile, titles map[string]string, opts Options) str
     ids := make([]string, 0, len(d.Nodes))
     // Map for quick lookup of critical path
     crit := make(map[string]bool, len(d.Nodes))
+    nodes := make(map[string]m.DagNode, len(d.Nodes))
     for _, n := range d.Nodes {
         ids = append(ids, n.ID)
+        nodes[n.ID] = n
         if n.CriticalPath {
             crit[n.ID] = true
         }
@@ -62,9 +65,9 @@ func FromDagWithOptions(d m.DagFile, titles map[string]string, opts Options) str
     for _, id := range ids {
         title := titles[id]
         label := nodeLabelFor(id, title, opts.NodeLabel)
-        attr := ""
+        attr := fmt.Sprintf(", tooltip=\"%s\"", escape(scheduleTooltip(nodes[id])))
         if crit[id] {
-            attr = ", color=red, penwidth=2"
+            attr += ", color=red, penwidth=2"
         }
         fmt.Fprintf(&b, "  %s [label=\"%s\"%s];\n", safeID(id), escape(label), attr)
     }
@@ -86,12 +89,31 @@ func FromDagWithOptions(d m.DagFile, titles map[string]string, opts Options) str
         // Edge label per options
         lbl := ""
         if opts.EdgeLabel == "type" {
+            // Merged parallel edges list every type.
             lbl = strings.TrimSpace(e.Type)
+            if len(e.Types) > 1 {
+                lbl = strings.Join(e.Types, "+")
+            }
         }
         edges = append(edges, edge{from: e.From, to: e.To, color: color, style: style, label: lbl})
     }
+    // Soft and low-confidence dependencies are drawn dashed and grey for review; they never
+    // constrain the schedule.
+    for _, e := range d.Analysis.SoftDeps {
+        if _, ok := nodes[e.From]; !ok {
+            continue
+        }
+        if _, ok := nodes[e.To]; !ok {
+            continue
+        }
+        lbl := ""
+        if opts.EdgeLabel == "type" {
+            lbl = strings.TrimSpace(fmt.Sprintf("%s %s", e.Type, formatFloat(e.Confidence)))
+        }
+        edges = append(edges, edge{from: e.From, to: e.To, color: "gray50", style: "dashed", label: lbl})
+    }
     // Deterministic ordering
-    sort.Slice(edges, func(i, j int) bool {
+    sort.SliceStable(edges, func(i, j int) bool {
         if edges[i].from == edges[j].from {
             return edges[i].to < edges[j].to
         }
@@ -110,6 +132,14 @@ func FromDagWithOptions(d m.DagFile, titles map[string]string, opts Options) str
     return b.String()
 }
 
+// scheduleTooltip summarizes a node's timing and parallelism for hover text in rendered SVGs.
+func scheduleTooltip(n m.DagNode) string {
+    return fmt.Sprintf("start %s-%sh, float %sh, parallel %d, unblocks %d",
+        formatFloat(n.EarliestStartHours), formatFloat(n.LatestStartHours), formatFloat(n.SlackHours), n.ParallelOpportunity, n.Unblocks)
+}
+
+func formatFloat(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+
 func kv(m map[string]string) string {
     parts := make([]string, 0, len(m))
     for k, v := range m {
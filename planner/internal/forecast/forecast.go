@@ -0,0 +1,283 @@
+// Package forecast estimates plan completion times by Monte Carlo sampling of PERT durations.
+package forecast
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+
+	m "github.com/james/tasks-planner/internal/model"
+	"github.com/james/tasks-planner/internal/pert"
+)
+
+// DefaultRuns is the number of samples drawn when Options.Runs is zero.
+const DefaultRuns = 1000
+
+// ErrCycle is returned when the precedence graph cannot be ordered.
+var ErrCycle = errors.New("forecast: precedence graph has a cycle")
+
+// Options configures a forecast. The same Seed and Runs always produce the same Result.
+type Options struct {
+	Runs int
+	Seed int64
+}
+
+// Percentiles summarizes a sampled distribution in hours.
+type Percentiles struct {
+	Mean float64 `json:"mean_hours"`
+	P50  float64 `json:"p50_hours"`
+	P80  float64 `json:"p80_hours"`
+	P95  float64 `json:"p95_hours"`
+}
+
+// Result is the outcome of Run.
+type Result struct {
+	Runs  int              `json:"runs"`
+	Seed  int64            `json:"seed"`
+	Total Percentiles      `json:"total"`
+	Waves []m.WaveEstimate `json:"waves"`
+}
+
+// Run samples every task duration Runs times and reports two views of each sample:
+//
+//   - Total: the makespan of a resource-constrained schedule over the coordinator graph. Tasks
+//     start in topological order as soon as their hard predecessors finish and the resources they
+//     need are free: exclusive resources and write access take every unit, limited needs take
+//     their units from the catalog capacity, and Policies.ConcurrencyMax caps running tasks.
+//     Limited needs on resources missing from the catalog are not constrained.
+//   - Waves: the completion time of each wave in waves, each paced by its longest member.
+func Run(coord m.Coordinator, waves [][]string, opts Options) (Result, error) {
+	runs := opts.Runs
+	if runs == 0 {
+		runs = DefaultRuns
+	}
+	if runs < 0 {
+		return Result{}, fmt.Errorf("forecast: runs %d must be positive", runs)
+	}
+	res := Result{Runs: runs, Seed: opts.Seed, Waves: []m.WaveEstimate{}}
+	s, err := newSchedule(coord)
+	if err != nil {
+		return res, err
+	}
+	waveIdx := make([][]int, len(waves))
+	for w, ids := range waves {
+		for _, id := range ids {
+			i, ok := s.index[id]
+			if !ok {
+				return res, fmt.Errorf("forecast: wave %d names unknown task %q", w, id)
+			}
+			waveIdx[w] = append(waveIdx[w], i)
+		}
+	}
+
+	rng := rand.New(rand.NewSource(opts.Seed))
+	durations := make([]float64, len(s.tasks))
+	totals := make([]float64, runs)
+	completions := make([][]float64, len(waves))
+	for w := range completions {
+		completions[w] = make([]float64, runs)
+	}
+	for r := 0; r < runs; r++ {
+		for i, t := range s.tasks {
+			durations[i] = pert.Hours(t, pert.Sample(rng, t.Duration))
+		}
+		totals[r] = s.makespan(durations)
+		elapsed := 0.0
+		for w, members := range waveIdx {
+			longest := 0.0
+			for _, i := range members {
+				longest = math.Max(longest, durations[i])
+			}
+			elapsed += longest
+			completions[w][r] = elapsed
+		}
+	}
+
+	res.Total = summarize(totals)
+	for w, samples := range completions {
+		p := summarize(samples)
+		res.Waves = append(res.Waves, m.WaveEstimate{Wave: w, P50Hours: p.P50, P80Hours: p.P80, P95Hours: p.P95})
+	}
+	return res, nil
+}
+
+// summarize sorts samples in place and reads nearest-rank percentiles.
+func summarize(samples []float64) Percentiles {
+	if len(samples) == 0 {
+		return Percentiles{}
+	}
+	sort.Float64s(samples)
+	sum := 0.0
+	for _, v := range samples {
+		sum += v
+	}
+	return Percentiles{
+		Mean: sum / float64(len(samples)),
+		P50:  percentile(samples, 0.50),
+		P80:  percentile(samples, 0.80),
+		P95:  percentile(samples, 0.95),
+	}
+}
+
+func percentile(sorted []float64, p float64) float64 {
+	i := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i]
+}
+
+// need is a claim on units of a resource pool.
+type need struct {
+	pool  int
+	units int
+}
+
+// schedule is the graph prepared once so each sample only replays the serial schedule.
+type schedule struct {
+	tasks []m.Task
+	index map[string]int
+	order []int
+	preds [][]int
+	needs [][]need
+	pools []int // capacity per pool
+}
+
+func newSchedule(coord m.Coordinator) (*schedule, error) {
+	s := &schedule{tasks: coord.Graph.Nodes, index: make(map[string]int, len(coord.Graph.Nodes))}
+	for i, t := range s.tasks {
+		if _, dup := s.index[t.ID]; dup {
+			return nil, fmt.Errorf("forecast: duplicate task %s", t.ID)
+		}
+		s.index[t.ID] = i
+	}
+	s.preds = make([][]int, len(s.tasks))
+	succs := make([][]int, len(s.tasks))
+	indeg := make([]int, len(s.tasks))
+	for _, e := range coord.Graph.Edges {
+		if !e.IsHard || e.Type == "resource" {
+			continue
+		}
+		from, okFrom := s.index[e.From]
+		to, okTo := s.index[e.To]
+		if !okFrom || !okTo {
+			return nil, fmt.Errorf("forecast: edge %s->%s references an unknown task", e.From, e.To)
+		}
+		s.preds[to] = append(s.preds[to], from)
+		succs[from] = append(succs[from], to)
+		indeg[to]++
+	}
+
+	// Kahn ordering with the smallest ID first keeps the schedule deterministic.
+	var ready []int
+	for i := range s.tasks {
+		if indeg[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+	for len(ready) > 0 {
+		sort.Slice(ready, func(a, b int) bool { return s.tasks[ready[a]].ID < s.tasks[ready[b]].ID })
+		i := ready[0]
+		ready = ready[1:]
+		s.order = append(s.order, i)
+		for _, j := range succs[i] {
+			if indeg[j]--; indeg[j] == 0 {
+				ready = append(ready, j)
+			}
+		}
+	}
+	if len(s.order) != len(s.tasks) {
+		var stuck []string
+		for i, t := range s.tasks {
+			if indeg[i] > 0 {
+				stuck = append(stuck, t.ID)
+			}
+		}
+		sort.Strings(stuck)
+		return nil, fmt.Errorf("%w: %s", ErrCycle, strings.Join(stuck, ", "))
+	}
+
+	pools := map[string]int{}
+	pool := func(name string, capacity int) int {
+		if p, ok := pools[name]; ok {
+			return p
+		}
+		pools[name] = len(s.pools)
+		s.pools = append(s.pools, capacity)
+		return pools[name]
+	}
+	catalog := coord.Config.Resources.Catalog
+	s.needs = make([][]need, len(s.tasks))
+	for i, t := range s.tasks {
+		units := map[string]int{}
+		for _, name := range t.Resources.Exclusive {
+			units[name] = capacityOf(catalog, name)
+		}
+		for _, ln := range t.Resources.Limited {
+			spec, ok := catalog[ln.Name]
+			if !ok || spec.Capacity <= 0 {
+				continue
+			}
+			n := ln.Units
+			if n <= 0 {
+				n = 1
+			}
+			if strings.EqualFold(ln.Access, m.AccessWrite) || spec.Mode == "exclusive" {
+				n = spec.Capacity
+			}
+			units[ln.Name] = min(max(units[ln.Name], n), spec.Capacity)
+		}
+		names := make([]string, 0, len(units))
+		for name := range units {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			s.needs[i] = append(s.needs[i], need{pool: pool("resource:"+name, capacityOf(catalog, name)), units: units[name]})
+		}
+		if c := coord.Config.Policies.ConcurrencyMax; c > 0 {
+			s.needs[i] = append(s.needs[i], need{pool: pool("slots", c), units: 1})
+		}
+	}
+	return s, nil
+}
+
+func capacityOf(catalog map[string]m.ResourceSpec, name string) int {
+	if spec, ok := catalog[name]; ok && spec.Capacity > 0 {
+		return spec.Capacity
+	}
+	return 1
+}
+
+// makespan places each task, in topological order, at the earliest time its predecessors have
+// finished and enough units of every pool it needs are free, then returns the latest finish.
+func (s *schedule) makespan(durations []float64) float64 {
+	free := make([][]float64, len(s.pools)) // per-unit release times
+	for p, capacity := range s.pools {
+		free[p] = make([]float64, capacity)
+	}
+	finish := make([]float64, len(s.tasks))
+	end := 0.0
+	for _, i := range s.order {
+		start := 0.0
+		for _, p := range s.preds[i] {
+			start = math.Max(start, finish[p])
+		}
+		for _, n := range s.needs[i] {
+			units := free[n.pool]
+			sort.Float64s(units)
+			start = math.Max(start, units[n.units-1])
+		}
+		finish[i] = start + durations[i]
+		for _, n := range s.needs[i] {
+			for u := 0; u < n.units; u++ {
+				free[n.pool][u] = finish[i]
+			}
+		}
+		end = math.Max(end, finish[i])
+	}
+	return end
+}
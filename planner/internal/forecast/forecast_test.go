@@ -0,0 +1,135 @@
+package forecast
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+
+	m "github.com/james/tasks-planner/internal/model"
+)
+
+func task(id string, o, ml, p float64) m.Task {
+	return m.Task{ID: id, Duration: m.DurationPERT{Optimistic: o, MostLikely: ml, Pessimistic: p}, DurationUnit: "hours"}
+}
+
+func coordinator(tasks []m.Task, edges ...m.Edge) m.Coordinator {
+	var c m.Coordinator
+	c.Graph.Nodes = tasks
+	c.Graph.Edges = edges
+	return c
+}
+
+func hard(from, to string) m.Edge {
+	return m.Edge{From: from, To: to, Type: "technical", IsHard: true}
+}
+
+func TestRunDeterministicForSeed(t *testing.T) {
+	coord := coordinator([]m.Task{task("A", 1, 2, 6), task("B", 2, 3, 9), task("C", 1, 1, 4)}, hard("A", "C"))
+	waves := [][]string{{"A", "B"}, {"C"}}
+	a, err := Run(coord, waves, Options{Runs: 500, Seed: 7})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	b, err := Run(coord, waves, Options{Runs: 500, Seed: 7})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !reflect.DeepEqual(a, b) {
+		t.Fatalf("same seed produced different results:\n%+v\n%+v", a, b)
+	}
+	c, _ := Run(coord, waves, Options{Runs: 500, Seed: 8})
+	if reflect.DeepEqual(a.Total, c.Total) {
+		t.Fatalf("different seeds produced identical totals %+v", a.Total)
+	}
+	if !(a.Total.P50 <= a.Total.P80 && a.Total.P80 <= a.Total.P95) {
+		t.Fatalf("percentiles out of order: %+v", a.Total)
+	}
+	if len(a.Waves) != 2 || a.Waves[1].P50Hours < a.Waves[0].P50Hours {
+		t.Fatalf("wave completions not cumulative: %+v", a.Waves)
+	}
+}
+
+func TestRunFixedDurations(t *testing.T) {
+	coord := coordinator([]m.Task{task("A", 2, 2, 2), task("B", 3, 3, 3), task("C", 1, 1, 1)}, hard("A", "C"))
+	res, err := Run(coord, [][]string{{"A", "B"}, {"C"}}, Options{Runs: 10, Seed: 1})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if res.Total.P50 != 3 || res.Total.P95 != 3 {
+		t.Fatalf("total = %+v, want 3h (B runs beside A->C)", res.Total)
+	}
+	want := []m.WaveEstimate{{Wave: 0, P50Hours: 3, P80Hours: 3, P95Hours: 3}, {Wave: 1, P50Hours: 4, P80Hours: 4, P95Hours: 4}}
+	if !reflect.DeepEqual(res.Waves, want) {
+		t.Fatalf("waves = %+v, want %+v", res.Waves, want)
+	}
+}
+
+func TestRunHonoursResourcesAndConcurrency(t *testing.T) {
+	a, b, c := task("A", 2, 2, 2), task("B", 2, 2, 2), task("C", 30, 30, 30)
+	c.DurationUnit = "minutes"
+	a.Resources.Exclusive = []string{"db"}
+	b.Resources.Exclusive = []string{"db"}
+	coord := coordinator([]m.Task{a, b, c})
+	res, err := Run(coord, nil, Options{Runs: 1})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if res.Total.P50 != 4 {
+		t.Fatalf("exclusive db: total = %v, want 4", res.Total.P50)
+	}
+
+	coord = coordinator([]m.Task{task("A", 1, 1, 1), task("B", 1, 1, 1), task("C", 1, 1, 1)})
+	coord.Config.Policies.ConcurrencyMax = 2
+	if res, _ = Run(coord, nil, Options{Runs: 1}); res.Total.P50 != 2 {
+		t.Fatalf("concurrency 2: total = %v, want 2", res.Total.P50)
+	}
+
+	d, e := task("D", 1, 1, 1), task("E", 1, 1, 1)
+	d.Resources.Limited = []m.ResourceNeed{{Name: "gpu", Units: 2}}
+	e.Resources.Limited = []m.ResourceNeed{{Name: "gpu", Units: 1}}
+	coord = coordinator([]m.Task{d, e})
+	coord.Config.Resources.Catalog = map[string]m.ResourceSpec{"gpu": {Capacity: 3}}
+	if res, _ = Run(coord, nil, Options{Runs: 1}); res.Total.P50 != 1 {
+		t.Fatalf("gpu fits both: total = %v, want 1", res.Total.P50)
+	}
+	coord.Config.Resources.Catalog["gpu"] = m.ResourceSpec{Capacity: 2}
+	if res, _ = Run(coord, nil, Options{Runs: 1}); res.Total.P50 != 2 {
+		t.Fatalf("gpu full: total = %v, want 2", res.Total.P50)
+	}
+}
+
+func TestRunPercentilesTrackPERT(t *testing.T) {
+	coord := coordinator([]m.Task{task("A", 1, 4, 13)})
+	res, err := Run(coord, [][]string{{"A"}}, Options{Runs: 20000, Seed: 3})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if math.Abs(res.Total.Mean-5) > 0.1 {
+		t.Fatalf("mean = %v, want ~5 (PERT mean)", res.Total.Mean)
+	}
+	if res.Total.P95 <= res.Total.P50 || res.Total.P95 > 13 {
+		t.Fatalf("p95 = %v (p50 %v)", res.Total.P95, res.Total.P50)
+	}
+	if res.Waves[0].P50Hours != res.Total.P50 {
+		t.Fatalf("single-task wave %v differs from total %v", res.Waves[0].P50Hours, res.Total.P50)
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	coord := coordinator([]m.Task{task("A", 1, 1, 1), task("B", 1, 1, 1)}, hard("A", "B"), hard("B", "A"))
+	if _, err := Run(coord, nil, Options{}); !errors.Is(err, ErrCycle) {
+		t.Fatalf("cycle: err = %v, want ErrCycle", err)
+	}
+	coord = coordinator([]m.Task{task("A", 1, 1, 1)})
+	if _, err := Run(coord, [][]string{{"Z"}}, Options{}); err == nil {
+		t.Fatal("expected error for unknown wave task")
+	}
+	if _, err := Run(coord, nil, Options{Runs: -1}); err == nil {
+		t.Fatal("expected error for negative runs")
+	}
+	res, err := Run(coord, nil, Options{})
+	if err != nil || res.Runs != DefaultRuns {
+		t.Fatalf("default runs = %d, err %v", res.Runs, err)
+	}
+}
@@ -0,0 +1,210 @@
+// Package httpapi serves the slapsd admin endpoints over HTTP.
+package httpapi
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	execapp "github.com/james/tasks-planner/internal/app/exec"
+	m "github.com/james/tasks-planner/internal/model"
+	"github.com/james/tasks-planner/internal/provenance"
+)
+
+// maxPatchBytes bounds POST /admin/patch bodies.
+const maxPatchBytes = 1 << 20
+
+// OperatorHeader lets a client name the operator behind an admin write. It is recorded as the
+// asserted operator only: the token, not the header, is what authenticates the request.
+const OperatorHeader = "X-Admin-Operator"
+
+// Admin exposes the running executor to operators:
+//
+//	GET  /admin/graph       live task states, telemetry conformance and edges
+//	GET  /admin/provenance  ledger records (?task_id, kind, since, until RFC 3339, limit)
+//	GET  /admin/breakers    circuit breaker states
+//	POST /admin/patch       apply a hot patch (m.Patch JSON body)
+//
+// Every request must carry "Authorization: Bearer <Token>". Writes are audited through Audit
+// whether they succeed or not, with the OperatorHeader value as the asserted operator.
+// Unset adapters answer 501.
+type Admin struct {
+	Token      string
+	Graph      func() (execapp.GraphSnapshot, error)
+	Provenance func(q provenance.Query) ([]provenance.Record, error)
+	Breakers   func() []execapp.BreakerState
+	ApplyPatch func(p m.Patch) (execapp.PatchResult, error)
+	Audit      func(a execapp.AdminAction) error
+}
+
+// Handler returns the admin routes. It refuses every request when Token is empty.
+func (a Admin) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/admin/graph", a.get(a.graph))
+	mux.HandleFunc("/admin/provenance", a.get(a.provenance))
+	mux.HandleFunc("/admin/breakers", a.get(a.breakers))
+	mux.HandleFunc("/admin/patch", a.authorized(a.patch))
+	return mux
+}
+
+func (a Admin) authorized(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if a.Token == "" || !ok || subtle.ConstantTimeCompare([]byte(token), []byte(a.Token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="slapsd"`)
+			writeError(w, http.StatusUnauthorized, errors.New("missing or invalid admin token"))
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (a Admin) get(next http.HandlerFunc) http.HandlerFunc {
+	return a.authorized(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("%s not allowed", r.Method))
+			return
+		}
+		next(w, r)
+	})
+}
+
+func (a Admin) graph(w http.ResponseWriter, r *http.Request) {
+	if a.Graph == nil {
+		writeError(w, http.StatusNotImplemented, errors.New("graph not available"))
+		return
+	}
+	snap, err := a.Graph()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, snap)
+}
+
+func (a Admin) provenance(w http.ResponseWriter, r *http.Request) {
+	if a.Provenance == nil {
+		writeError(w, http.StatusNotImplemented, errors.New("provenance ledger not enabled"))
+		return
+	}
+	q, err := parseQuery(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	recs, err := a.Provenance(q)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if recs == nil {
+		recs = []provenance.Record{}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"records": recs})
+}
+
+func parseQuery(r *http.Request) (provenance.Query, error) {
+	v := r.URL.Query()
+	q := provenance.Query{TaskID: v.Get("task_id"), Kind: v.Get("kind")}
+	for name, dst := range map[string]*time.Time{"since": &q.Since, "until": &q.Until} {
+		if s := v.Get(name); s != "" {
+			t, err := time.Parse(time.RFC3339Nano, s)
+			if err != nil {
+				return q, fmt.Errorf("%s: %w", name, err)
+			}
+			*dst = t
+		}
+	}
+	if s := v.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			return q, fmt.Errorf("limit: %q is not a non-negative integer", s)
+		}
+		q.Limit = n
+	}
+	return q, nil
+}
+
+func (a Admin) breakers(w http.ResponseWriter, r *http.Request) {
+	if a.Breakers == nil {
+		writeError(w, http.StatusNotImplemented, errors.New("breakers not available"))
+		return
+	}
+	states := a.Breakers()
+	if states == nil {
+		states = []execapp.BreakerState{}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"breakers": states})
+}
+
+func (a Admin) patch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("%s not allowed", r.Method))
+		return
+	}
+	if a.ApplyPatch == nil {
+		writeError(w, http.StatusNotImplemented, errors.New("patching not available"))
+		return
+	}
+	var p m.Patch
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxPatchBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&p); err != nil {
+		err = fmt.Errorf("decode patch: %w", err)
+		if aerr := a.audit(r, "patch", map[string]any{"status": "invalid"}, err); aerr != nil {
+			writeError(w, http.StatusInternalServerError, aerr)
+			return
+		}
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	res, err := a.ApplyPatch(p)
+	summary := map[string]any{"patch_id": p.ID, "reason": p.Reason, "ops": len(p.Ops), "status": "applied"}
+	if err != nil {
+		summary["status"] = "rejected"
+	} else {
+		summary["graph_hash"] = res.GraphHash
+	}
+	if aerr := a.audit(r, "patch", summary, err); aerr != nil {
+		if err == nil {
+			aerr = fmt.Errorf("patch %s applied but not audited: %w", p.ID, aerr)
+		}
+		writeError(w, http.StatusInternalServerError, aerr)
+		return
+	}
+	switch {
+	case errors.Is(err, execapp.ErrPatchRejected):
+		writeError(w, http.StatusUnprocessableEntity, err)
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, err)
+	default:
+		writeJSON(w, http.StatusOK, res)
+	}
+}
+
+func (a Admin) audit(r *http.Request, verb string, summary map[string]any, err error) error {
+	if a.Audit == nil {
+		return nil
+	}
+	op := strings.TrimSpace(r.Header.Get(OperatorHeader))
+	return a.Audit(execapp.AdminAction{AssertedOperator: op, Verb: verb, Summary: summary, Err: err})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
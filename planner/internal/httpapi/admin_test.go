@@ -0,0 +1,115 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	execapp "github.com/james/tasks-planner/internal/app/exec"
+	m "github.com/james/tasks-planner/internal/model"
+	"github.com/james/tasks-planner/internal/provenance"
+)
+
+func newAdmin(audit *[]execapp.AdminAction) Admin {
+	return Admin{
+		Token: "s3cret",
+		Graph: func() (execapp.GraphSnapshot, error) {
+			return execapp.GraphSnapshot{GraphHash: "abc", Tasks: []execapp.TaskView{{ID: "T001", State: execapp.TaskRunning}}}, nil
+		},
+		Provenance: func(q provenance.Query) ([]provenance.Record, error) {
+			return []provenance.Record{{Seq: 1, Kind: provenance.KindDispatch, TaskID: q.TaskID}}, nil
+		},
+		Breakers: func() []execapp.BreakerState { return nil },
+		ApplyPatch: func(p m.Patch) (execapp.PatchResult, error) {
+			if len(p.Ops) == 0 {
+				return execapp.PatchResult{PatchID: p.ID}, fmt.Errorf("%w: %s has no ops", execapp.ErrPatchRejected, p.ID)
+			}
+			return execapp.PatchResult{PatchID: p.ID, GraphHash: "def"}, nil
+		},
+		Audit: func(a execapp.AdminAction) error {
+			*audit = append(*audit, a)
+			return nil
+		},
+	}
+}
+
+func do(h http.Handler, method, target, token, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	req.Header.Set(OperatorHeader, "alice")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAdminRequiresToken(t *testing.T) {
+	var audit []execapp.AdminAction
+	h := newAdmin(&audit).Handler()
+	for _, token := range []string{"", "wrong"} {
+		if rec := do(h, http.MethodGet, "/admin/graph", token, ""); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("token %q: expected 401, got %d", token, rec.Code)
+		}
+	}
+	if rec := do(Admin{}.Handler(), http.MethodGet, "/admin/graph", "", ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected an unconfigured token to refuse everything, got %d", rec.Code)
+	}
+}
+
+func TestAdminReadEndpoints(t *testing.T) {
+	var audit []execapp.AdminAction
+	h := newAdmin(&audit).Handler()
+	rec := do(h, http.MethodGet, "/admin/graph", "s3cret", "")
+	var snap execapp.GraphSnapshot
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &snap) != nil || snap.Tasks[0].State != execapp.TaskRunning {
+		t.Fatalf("unexpected graph response %d %s", rec.Code, rec.Body)
+	}
+	rec = do(h, http.MethodGet, "/admin/provenance?task_id=T001&since=2026-01-01T00:00:00Z&limit=5", "s3cret", "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"task_id": "T001"`) {
+		t.Fatalf("unexpected provenance response %d %s", rec.Code, rec.Body)
+	}
+	if rec = do(h, http.MethodGet, "/admin/provenance?since=yesterday", "s3cret", ""); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a bad time, got %d", rec.Code)
+	}
+	rec = do(h, http.MethodGet, "/admin/breakers", "s3cret", "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"breakers": []`) {
+		t.Fatalf("unexpected breakers response %d %s", rec.Code, rec.Body)
+	}
+	if rec = do(h, http.MethodPost, "/admin/graph", "s3cret", ""); rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", rec.Code)
+	}
+	if len(audit) != 0 {
+		t.Fatalf("reads must not be audited, got %+v", audit)
+	}
+}
+
+func TestAdminPatchIsAudited(t *testing.T) {
+	var audit []execapp.AdminAction
+	h := newAdmin(&audit).Handler()
+	body := `{"id":"P1","reason":"hotfix","ops":[{"op":"modify_resource","resource":"db","spec":{"capacity":2,"mode":"semaphore","lock_order":0}}]}`
+	rec := do(h, http.MethodPost, "/admin/patch", "s3cret", body)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"graph_hash": "def"`) {
+		t.Fatalf("unexpected patch response %d %s", rec.Code, rec.Body)
+	}
+	if rec = do(h, http.MethodPost, "/admin/patch", "s3cret", `{"id":"P2","ops":[]}`); rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422 for a rejected patch, got %d", rec.Code)
+	}
+	if rec = do(h, http.MethodPost, "/admin/patch", "s3cret", `{"id":`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for malformed JSON, got %d", rec.Code)
+	}
+	if len(audit) != 3 {
+		t.Fatalf("expected every write audited, got %+v", audit)
+	}
+	first, second := audit[0], audit[1]
+	if first.AssertedOperator != "alice" || first.Verb != "patch" || first.Summary["status"] != "applied" || first.Err != nil {
+		t.Fatalf("unexpected audit %+v", first)
+	}
+	if second.Summary["status"] != "rejected" || !errors.Is(second.Err, execapp.ErrPatchRejected) {
+		t.Fatalf("unexpected audit %+v", second)
+	}
+}
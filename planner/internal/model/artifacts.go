@@ -20,8 +20,18 @@ type FeatureEntry struct {
 
 // WavesArtifact models waves.json with versioned metadata.
 type WavesArtifact struct {
-	Meta  WavesMeta  `json:"meta"`
-	Waves [][]string `json:"waves"`
+	Meta      WavesMeta      `json:"meta"`
+	Waves     [][]string     `json:"waves"`
+	Estimates []WaveEstimate `json:"estimates,omitempty"`
+}
+
+// WaveEstimate forecasts when wave Wave (zero-based) completes, in hours from the start of the
+// plan, assuming each wave is paced by its longest member.
+type WaveEstimate struct {
+	Wave     int     `json:"wave"`
+	P50Hours float64 `json:"p50_hours"`
+	P80Hours float64 `json:"p80_hours"`
+	P95Hours float64 `json:"p95_hours"`
 }
 
 // WavesMeta extends artifact metadata with the plan identifier reference.
@@ -7,6 +7,65 @@ type ResourceSpec struct {
 	LockOrder int    `json:"lock_order"`
 }
 
+// Circuit breaker actions.
+const (
+	BreakerActionPause             = "pause"
+	BreakerActionReduceConcurrency = "reduce_concurrency"
+)
+
+// CircuitBreaker trips when a telemetry failure fingerprint repeats Threshold times within
+// WindowSeconds. A fingerprint matches when its error code equals ErrorCode and its message
+// matches Pattern (either may be omitted, not both). While open for CooldownSeconds (defaults to
+// the window) the breaker pauses dispatch of the tasks it scopes (Tasks and Resources; all tasks
+// when both are empty) or caps global concurrency at Concurrency.
+type CircuitBreaker struct {
+	Name            string   `json:"name"`
+	ErrorCode       string   `json:"error_code,omitempty"`
+	Pattern         string   `json:"pattern,omitempty"`
+	WindowSeconds   int      `json:"window_seconds"`
+	Threshold       int      `json:"threshold"`
+	CooldownSeconds int      `json:"cooldown_seconds,omitempty"`
+	Action          string   `json:"action"`
+	Concurrency     int      `json:"concurrency,omitempty"`
+	Tasks           []string `json:"tasks,omitempty"`
+	Resources       []string `json:"resources,omitempty"`
+}
+
+// RetryPolicy lets a failed task be dispatched again, up to MaxAttempts in total, before it is
+// declared failed and its dependents are blocked. Attempt n+1 waits BackoffSeconds *
+// BackoffMultiplier^(n-1) (multiplier defaults to 2), capped at MaxBackoffSeconds when set. When
+// RetryableExitCodes or RetryableErrorCodes (telemetry data.error_code) are listed, only failures
+// matching one of them are retried; otherwise every failure is.
+type RetryPolicy struct {
+	MaxAttempts         int      `json:"max_attempts"`
+	BackoffSeconds      float64  `json:"backoff_seconds,omitempty"`
+	BackoffMultiplier   float64  `json:"backoff_multiplier,omitempty"`
+	MaxBackoffSeconds   float64  `json:"max_backoff_seconds,omitempty"`
+	RetryableExitCodes  []int    `json:"retryable_exit_codes,omitempty"`
+	RetryableErrorCodes []string `json:"retryable_error_codes,omitempty"`
+}
+
+// PriorityWeights tune the executor's frontier ordering. A ready task scores
+//
+//	FanOut*downstream/maxDownstream - Depth*depth/maxDepth + Confidence*meanEvidenceConfidence
+//	- RollbackCost*(1 if not idempotent) + AgingPerMinute*minutesReady
+//
+// and higher scores dispatch first. Depth and downstream task counts are normalized to [0, 1]
+// across the graph. A zero value selects DefaultPriorityWeights.
+type PriorityWeights struct {
+	Depth          float64 `json:"depth"`
+	FanOut         float64 `json:"fan_out"`
+	Confidence     float64 `json:"confidence"`
+	RollbackCost   float64 `json:"rollback_cost"`
+	AgingPerMinute float64 `json:"aging_per_minute"`
+}
+
+// DefaultPriorityWeights favour tasks that unblock the most downstream work (the critical path)
+// while aging keeps low-scoring tasks from starving.
+func DefaultPriorityWeights() PriorityWeights {
+	return PriorityWeights{Depth: 1, FanOut: 2, Confidence: 0.5, RollbackCost: 0.5, AgingPerMinute: 0.1}
+}
+
 // Coordinator represents the coordinator.json contract passed from the planner to the executor.
 type Coordinator struct {
 	Version string `json:"version"`
@@ -20,14 +79,21 @@ type Coordinator struct {
 			Profiles map[string]map[string]int `json:"profiles"`
 		} `json:"resources"`
 		Policies struct {
-			ConcurrencyMax           int            `json:"concurrency_max"`
-			LockOrdering             []string       `json:"lock_ordering"`
-			CircuitBreakerThresholds map[string]any `json:"circuit_breaker_thresholds"`
+			ConcurrencyMax  int              `json:"concurrency_max"`
+			LockOrdering    []string         `json:"lock_ordering"`
+			CircuitBreakers []CircuitBreaker `json:"circuit_breakers"`
+			Retry           RetryPolicy      `json:"retry"`
+			Priority        PriorityWeights  `json:"priority"`
+			// MaxCompensations bounds how often a failed non-idempotent task whose retry policy allows
+			// no retries is rolled back and requeued before its failure is final (1 when zero).
+			MaxCompensations int `json:"max_compensations,omitempty"`
 		} `json:"policies"`
 	} `json:"config"`
 	Metrics struct {
 		Estimates struct {
 			P50TotalHours     float64 `json:"p50_total_hours"`
+			P80TotalHours     float64 `json:"p80_total_hours"`
+			P95TotalHours     float64 `json:"p95_total_hours"`
 			LongestPathLength int     `json:"longest_path_length"`
 			WidthApprox       int     `json:"width_approx"`
 		} `json:"estimates"`
@@ -7,20 +7,34 @@ type DagMeta struct {
 	TasksHash    string `json:"tasks_hash"`
 }
 
-// DagNode represents a node entry in dag.json.
+// DagNode represents a node entry in dag.json. ParallelOpportunity is the size of the node's Kahn
+// layer (tasks that may run alongside it) and Unblocks counts its direct dependents. Times are
+// hours from the start of the plan under PERT expected durations: EarliestStartHours and
+// LatestStartHours bound when the task can start without delaying the plan, and SlackHours is
+// their difference (total float, zero on the critical path).
 type DagNode struct {
-	ID                  string `json:"id"`
-	Depth               int    `json:"depth"`
-	CriticalPath        bool   `json:"critical_path"`
-	ParallelOpportunity int    `json:"parallel_opportunity"`
+	ID                  string  `json:"id"`
+	Depth               int     `json:"depth"`
+	CriticalPath        bool    `json:"critical_path"`
+	ParallelOpportunity int     `json:"parallel_opportunity"`
+	Unblocks            int     `json:"unblocks"`
+	DurationHours       float64 `json:"duration_hours"`
+	EarliestStartHours  float64 `json:"earliest_start_hours"`
+	LatestStartHours    float64 `json:"latest_start_hours"`
+	SlackHours          float64 `json:"slack_hours"`
 }
 
-// DagEdge represents an edge entry in dag.json.
+// DagEdge represents an edge entry in dag.json. Parallel input edges between the same pair are
+// merged: Types lists every distinct type in input order (Type is the first), Confidence is the
+// highest and Evidence is the union of theirs.
 type DagEdge struct {
-	From       string `json:"from"`
-	To         string `json:"to"`
-	Type       string `json:"type"`
-	Transitive bool   `json:"transitive"`
+	From       string     `json:"from"`
+	To         string     `json:"to"`
+	Type       string     `json:"type"`
+	Types      []string   `json:"types"`
+	Confidence float64    `json:"confidence"`
+	Evidence   []Evidence `json:"evidence,omitempty"`
+	Transitive bool       `json:"transitive"`
 }
 
 // DagMetrics records aggregate measurements for the DAG.
@@ -32,8 +46,9 @@ type DagMetrics struct {
 	Edges                int            `json:"edges"`
 	EdgeDensity          float64        `json:"edge_density"`
 	WidthApprox          int            `json:"width_approx"`
-	LongestPathLength    int            `json:"longest_path_length"`
-	CriticalPath         []string       `json:"critical_path"`
+	LongestPathLength    int            `json:"longest_path_length"` // tasks on the longest chain by hop count
+	CriticalPath         []string       `json:"critical_path"`       // weighted by expected duration
+	CriticalPathHours    float64        `json:"critical_path_hours"`
 	IsolatedTasks        int            `json:"isolated_tasks"`
 	VerbFirstPct         float64        `json:"verb_first_pct"`
 	EvidenceCoverage     float64        `json:"evidence_coverage"`
@@ -41,10 +56,34 @@ type DagMetrics struct {
 
 // DagAnalysis carries validation results for the DAG.
 type DagAnalysis struct {
-	OK       bool     `json:"ok"`
-	Errors   []string `json:"errors"`
-	Warnings []string `json:"warnings"`
-	SoftDeps []Edge   `json:"soft_deps"`
+	OK       bool              `json:"ok"`
+	Errors   []string          `json:"errors"`
+	Warnings []string          `json:"warnings"`
+	SoftDeps []Edge            `json:"soft_deps"`
+	Cycles   []CycleDiagnostic `json:"cycles,omitempty"`
+}
+
+// CycleDiagnostic describes one strongly connected component of the precedence graph: its tasks,
+// one concrete cycle through them (first task repeated at the end), every edge inside it, and the
+// edges ranked cheapest to break first.
+type CycleDiagnostic struct {
+	Tasks           []string         `json:"tasks"`
+	Cycle           []string         `json:"cycle"`
+	Edges           []Edge           `json:"edges"`
+	BreakCandidates []BreakCandidate `json:"break_candidates"`
+	Suggestions     []string         `json:"suggestions"`
+}
+
+// BreakCandidate is an edge inside a cycle proposed for removal. BreaksCycle reports whether
+// dropping it alone leaves the component acyclic; Rank 1 is the best candidate.
+type BreakCandidate struct {
+	Rank        int     `json:"rank"`
+	From        string  `json:"from"`
+	To          string  `json:"to"`
+	Type        string  `json:"type"`
+	Confidence  float64 `json:"confidence"`
+	BreaksCycle bool    `json:"breaks_cycle"`
+	Reason      string  `json:"reason"`
 }
 
 // DagFile represents the canonical dag.json artifact.
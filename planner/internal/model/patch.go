@@ -0,0 +1,26 @@
+package model
+
+// Patch operations accepted by the executor's hot-patch engine.
+const (
+	PatchAddTask        = "add_task"
+	PatchAddEdge        = "add_edge"
+	PatchModifyResource = "modify_resource"
+)
+
+// Patch is a hot update applied to a running plan. Patches only add tasks and edges or adjust
+// resource policies; they never remove hard structural edges.
+type Patch struct {
+	ID     string    `json:"id"`
+	Reason string    `json:"reason,omitempty"`
+	Ops    []PatchOp `json:"ops"`
+}
+
+// PatchOp is one operation in a Patch. Task is set for add_task, Edge for add_edge, and Resource
+// with Spec for modify_resource (which upserts the catalog entry).
+type PatchOp struct {
+	Op       string        `json:"op"`
+	Task     *Task         `json:"task,omitempty"`
+	Edge     *Edge         `json:"edge,omitempty"`
+	Resource string        `json:"resource,omitempty"`
+	Spec     *ResourceSpec `json:"spec,omitempty"`
+}
@@ -1,5 +1,11 @@
 package model
 
+// Access modes for ResourceNeed.Access. An empty Access counts against capacity like a read.
+const (
+	AccessRead  = "read"
+	AccessWrite = "write"
+)
+
 // ResourceNeed defines a requirement for a shared, limited resource.
 type ResourceNeed struct {
 	Name   string `json:"name"`
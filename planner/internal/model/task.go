@@ -7,6 +7,7 @@ type Task struct {
 	Title              string              `json:"title"`
 	Description        string              `json:"description,omitempty"`
 	Category           string              `json:"category,omitempty"`
+	Capabilities       []string            `json:"capabilities,omitempty"`
 	Duration           DurationPERT        `json:"duration"`
 	DurationUnit       string              `json:"durationUnits"`
 	InterfacesProduced []InterfaceProduced `json:"interfaces_produced,omitempty"`
@@ -25,4 +26,6 @@ type Task struct {
 		Idempotent  bool   `json:"idempotent"`
 		RollbackCmd string `json:"rollback_cmd,omitempty"`
 	} `json:"compensation"`
+	// Retry overrides Config.Policies.Retry for this task when set.
+	Retry *RetryPolicy `json:"retry,omitempty"`
 }
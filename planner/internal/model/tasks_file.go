@@ -8,8 +8,9 @@ type TasksFile struct {
 		ArtifactHash      string  `json:"artifact_hash"`
 		CodebaseAnalysis  any     `json:"codebase_analysis"`
 		Autonormalization struct {
-			Split  []string `json:"split"`
-			Merged []string `json:"merged"`
+			Split        []string      `json:"split"`
+			Merged       []string      `json:"merged"`
+			CycleRepairs []CycleRepair `json:"cycle_repairs,omitempty"`
 		} `json:"autonormalization"`
 		ValidatorReports []ValidatorReport `json:"validator_reports,omitempty"`
 	} `json:"meta"`
@@ -17,3 +18,14 @@ type TasksFile struct {
 	Dependencies      []Edge         `json:"dependencies"`
 	ResourceConflicts map[string]any `json:"resource_conflicts,omitempty"`
 }
+
+// CycleRepair records a contract task inserted to break a dependency cycle: the Removed edges from
+// Producer to the Consumers of Interface were replaced by edges from Contract to all of them.
+type CycleRepair struct {
+	Pass      int      `json:"pass"`
+	Contract  string   `json:"contract"`
+	Interface string   `json:"interface"`
+	Producer  string   `json:"producer"`
+	Consumers []string `json:"consumers"`
+	Removed   []Edge   `json:"removed"`
+}
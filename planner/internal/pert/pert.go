@@ -0,0 +1,60 @@
+// Package pert samples task durations from three-point PERT estimates.
+package pert
+
+import (
+	"math"
+	"math/rand"
+
+	m "github.com/james/tasks-planner/internal/model"
+)
+
+// Mean returns the PERT expected duration (o + 4m + p) / 6.
+func Mean(d m.DurationPERT) float64 {
+	return (d.Optimistic + 4*d.MostLikely + d.Pessimistic) / 6
+}
+
+// Hours converts a duration d in t's DurationUnit to hours, treating any unit but "minutes" as
+// hours and clamping negative estimates to zero.
+func Hours(t m.Task, d float64) float64 {
+	if t.DurationUnit == "minutes" {
+		d /= 60
+	}
+	return math.Max(d, 0)
+}
+
+// StdDev returns the PERT standard deviation (p - o) / 6.
+func StdDev(d m.DurationPERT) float64 {
+	return (d.Pessimistic - d.Optimistic) / 6
+}
+
+// Sample draws a duration from the Beta-PERT distribution over [Optimistic, Pessimistic] with mode
+// MostLikely. Degenerate or inverted estimates return MostLikely (clamped at zero).
+func Sample(rng *rand.Rand, d m.DurationPERT) float64 {
+	lo, mode, hi := d.Optimistic, d.MostLikely, d.Pessimistic
+	if hi <= lo || mode < lo || mode > hi {
+		return math.Max(mode, 0)
+	}
+	alpha := 1 + 4*(mode-lo)/(hi-lo)
+	beta := 1 + 4*(hi-mode)/(hi-lo)
+	x := gamma(rng, alpha)
+	y := gamma(rng, beta)
+	return lo + (hi-lo)*x/(x+y)
+}
+
+// gamma draws from Gamma(shape, 1) for shape >= 1 (Marsaglia and Tsang).
+func gamma(rng *rand.Rand, shape float64) float64 {
+	d := shape - 1.0/3
+	c := 1 / math.Sqrt(9*d)
+	for {
+		x := rng.NormFloat64()
+		v := 1 + c*x
+		if v <= 0 {
+			continue
+		}
+		v = v * v * v
+		u := rng.Float64()
+		if u < 1-0.0331*x*x*x*x || math.Log(u) < 0.5*x*x+d*(1-v+math.Log(v)) {
+			return d * v
+		}
+	}
+}
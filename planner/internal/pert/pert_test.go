@@ -0,0 +1,58 @@
+package pert
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	m "github.com/james/tasks-planner/internal/model"
+)
+
+func TestSampleStaysInRangeAndMatchesMean(t *testing.T) {
+	d := m.DurationPERT{Optimistic: 1, MostLikely: 2, Pessimistic: 6}
+	rng := rand.New(rand.NewSource(7))
+	sum := 0.0
+	const n = 20000
+	for i := 0; i < n; i++ {
+		v := Sample(rng, d)
+		if v < d.Optimistic || v > d.Pessimistic {
+			t.Fatalf("sample %g outside [%g, %g]", v, d.Optimistic, d.Pessimistic)
+		}
+		sum += v
+	}
+	if got, want := sum/n, Mean(d); math.Abs(got-want) > 0.05 {
+		t.Fatalf("sample mean %g, want about %g", got, want)
+	}
+}
+
+func TestSampleDegenerateEstimates(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	if got := Sample(rng, m.DurationPERT{Optimistic: 2, MostLikely: 2, Pessimistic: 2}); got != 2 {
+		t.Fatalf("expected 2, got %g", got)
+	}
+	if got := Sample(rng, m.DurationPERT{Optimistic: 3, MostLikely: 1, Pessimistic: 2}); got != 1 {
+		t.Fatalf("expected the most likely value for inverted estimates, got %g", got)
+	}
+	if StdDev(m.DurationPERT{Optimistic: 1, Pessimistic: 7}) != 1 {
+		t.Fatal("unexpected standard deviation")
+	}
+}
+
+func TestHoursConvertsMinutesAndClamps(t *testing.T) {
+	cases := []struct {
+		unit string
+		d    float64
+		want float64
+	}{
+		{"hours", 3, 3},
+		{"", 3, 3},
+		{"minutes", 90, 1.5},
+		{"minutes", -30, 0},
+		{"hours", -1, 0},
+	}
+	for _, c := range cases {
+		if got := Hours(m.Task{DurationUnit: c.unit}, c.d); got != c.want {
+			t.Fatalf("Hours(%q, %g) = %g, want %g", c.unit, c.d, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package dag
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	m "github.com/james/tasks-planner/internal/model"
+)
+
+// ErrCycle is returned by Build when the structural edges contain a cycle; Analysis.Cycles then
+// describes each one.
+var ErrCycle = errors.New("cycle detected")
+
+// maxBreakCandidates bounds the ranked edges reported per cycle.
+const maxBreakCandidates = 5
+
+// breakCheckLimit bounds the component size (in edges) for which each candidate is test-removed.
+const breakCheckLimit = 256
+
+// typeBreakCost orders dependency types from cheapest to costliest to break: knowledge edges only
+// de-risk, sequential ones encode information flow, infrastructure and technical edges carry
+// real artifacts. Unknown types sit in the middle.
+var typeBreakCost = map[string]int{"knowledge": 0, "sequential": 1, "infrastructure": 3, "technical": 4}
+
+func breakCost(typ string) int {
+	if c, ok := typeBreakCost[typ]; ok {
+		return c
+	}
+	return 2
+}
+
+// cycleDiagnostics returns one diagnostic per strongly connected component that contains a cycle
+// (including self-loops), ordered by the component's smallest task ID.
+func cycleDiagnostics(tasks []m.Task, idx map[string]int, edges []m.Edge) []m.CycleDiagnostic {
+	n := len(tasks)
+	adj := make([][]int, n)
+	for _, e := range edges {
+		adj[idx[e.From]] = append(adj[idx[e.From]], idx[e.To])
+	}
+	comp := tarjan(adj)
+
+	members := map[int][]int{}
+	for v, c := range comp {
+		members[c] = append(members[c], v)
+	}
+	inner := map[int][]m.Edge{}
+	for _, e := range edges {
+		if c := comp[idx[e.From]]; c == comp[idx[e.To]] {
+			inner[c] = append(inner[c], e)
+		}
+	}
+
+	var out []m.CycleDiagnostic
+	for c, vs := range members {
+		es := inner[c]
+		if len(vs) == 1 && len(es) == 0 {
+			continue
+		}
+		ids := make([]string, 0, len(vs))
+		for _, v := range vs {
+			ids = append(ids, tasks[v].ID)
+		}
+		sort.Strings(ids)
+		sortEdges(es)
+		d := m.CycleDiagnostic{Tasks: ids, Edges: es, Cycle: shortestCycle(ids[0], es)}
+		d.BreakCandidates = rankBreakCandidates(ids, es)
+		d.Suggestions = cycleSuggestions(tasks, idx, d)
+		out = append(out, d)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Tasks[0] < out[j].Tasks[0] })
+	return out
+}
+
+// tarjan labels each vertex with its strongly connected component.
+func tarjan(adj [][]int) []int {
+	n := len(adj)
+	index := make([]int, n)
+	low := make([]int, n)
+	comp := make([]int, n)
+	onStack := make([]bool, n)
+	for i := range index {
+		index[i] = -1
+	}
+	var stack []int
+	next, comps := 0, 0
+	var visit func(int)
+	visit = func(u int) {
+		index[u], low[u] = next, next
+		next++
+		stack = append(stack, u)
+		onStack[u] = true
+		for _, v := range adj[u] {
+			if index[v] < 0 {
+				visit(v)
+				low[u] = min(low[u], low[v])
+			} else if onStack[v] {
+				low[u] = min(low[u], index[v])
+			}
+		}
+		if low[u] != index[u] {
+			return
+		}
+		for {
+			v := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[v] = false
+			comp[v] = comps
+			if v == u {
+				break
+			}
+		}
+		comps++
+	}
+	for u := 0; u < n; u++ {
+		if index[u] < 0 {
+			visit(u)
+		}
+	}
+	return comp
+}
+
+// shortestCycle finds, by BFS over edges, the shortest cycle through start.
+func shortestCycle(start string, edges []m.Edge) []string {
+	succ := map[string][]string{}
+	for _, e := range edges {
+		succ[e.From] = append(succ[e.From], e.To)
+	}
+	prev := map[string]string{}
+	queue := []string{start}
+	seen := map[string]bool{}
+	for len(queue) > 0 {
+		u := queue[0]
+		queue = queue[1:]
+		for _, v := range succ[u] {
+			if v == start {
+				path := []string{start}
+				for x := u; x != start; x = prev[x] {
+					path = append(path, x)
+				}
+				path = append(path, start)
+				for i, j := 1, len(path)-2; i < j; i, j = i+1, j-1 {
+					path[i], path[j] = path[j], path[i]
+				}
+				return path
+			}
+			if !seen[v] {
+				seen[v] = true
+				prev[v] = u
+				queue = append(queue, v)
+			}
+		}
+	}
+	return nil
+}
+
+// rankBreakCandidates orders the component's edges: those whose removal alone leaves it acyclic
+// first, then by ascending confidence, type break cost and evidence.
+func rankBreakCandidates(ids []string, edges []m.Edge) []m.BreakCandidate {
+	breaks := make([]bool, len(edges))
+	if len(edges) <= breakCheckLimit {
+		for i := range edges {
+			breaks[i] = acyclicWithout(ids, edges, i)
+		}
+	}
+	order := make([]int, len(edges))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		ea, eb := edges[order[a]], edges[order[b]]
+		switch {
+		case breaks[order[a]] != breaks[order[b]]:
+			return breaks[order[a]]
+		case ea.Confidence != eb.Confidence:
+			return ea.Confidence < eb.Confidence
+		case breakCost(ea.Type) != breakCost(eb.Type):
+			return breakCost(ea.Type) < breakCost(eb.Type)
+		default:
+			return len(ea.Evidence) < len(eb.Evidence)
+		}
+	})
+	if len(order) > maxBreakCandidates {
+		order = order[:maxBreakCandidates]
+	}
+	out := make([]m.BreakCandidate, 0, len(order))
+	for rank, i := range order {
+		e := edges[i]
+		reason := fmt.Sprintf("%s edge, confidence %.2f, %d evidence", edgeTypeKey(e.Type), e.Confidence, len(e.Evidence))
+		if breaks[i] {
+			reason = "removing it alone breaks the cycle; " + reason
+		}
+		out = append(out, m.BreakCandidate{
+			Rank: rank + 1, From: e.From, To: e.To, Type: e.Type, Confidence: e.Confidence,
+			BreaksCycle: breaks[i], Reason: reason,
+		})
+	}
+	return out
+}
+
+// acyclicWithout reports whether the component stays acyclic once edges[skip] is removed.
+func acyclicWithout(ids []string, edges []m.Edge, skip int) bool {
+	indeg := make(map[string]int, len(ids))
+	succ := map[string][]string{}
+	for i, e := range edges {
+		if i == skip {
+			continue
+		}
+		succ[e.From] = append(succ[e.From], e.To)
+		indeg[e.To]++
+	}
+	var queue []string
+	for _, id := range ids {
+		if indeg[id] == 0 {
+			queue = append(queue, id)
+		}
+	}
+	done := 0
+	for len(queue) > 0 {
+		u := queue[0]
+		queue = queue[1:]
+		done++
+		for _, v := range succ[u] {
+			if indeg[v]--; indeg[v] == 0 {
+				queue = append(queue, v)
+			}
+		}
+	}
+	return done == len(ids)
+}
+
+// cycleSuggestions proposes remedies for the best break candidate and the busiest task.
+func cycleSuggestions(tasks []m.Task, idx map[string]int, d m.CycleDiagnostic) []string {
+	if len(d.BreakCandidates) == 0 {
+		return nil
+	}
+	best := d.BreakCandidates[0]
+	if best.From == best.To {
+		return []string{fmt.Sprintf("remove the self-dependency on %s", best.From)}
+	}
+	out := []string{
+		fmt.Sprintf("drop or soften %s -> %s (%s, confidence %.2f); check the 'after:' line of %s", best.From, best.To, edgeTypeKey(best.Type), best.Confidence, best.To),
+		fmt.Sprintf("insert a contract task that fixes the interface between %s and %s so both depend on it instead of each other", best.From, best.To),
+	}
+	// The task touching most in-cycle edges is the likeliest to bundle two concerns.
+	degree := map[string]int{}
+	for _, e := range d.Edges {
+		degree[e.From]++
+		degree[e.To]++
+	}
+	hub := d.Tasks[0]
+	for _, id := range d.Tasks {
+		if degree[id] > degree[hub] {
+			hub = id
+		}
+	}
+	split := fmt.Sprintf("split %s at its interface boundary so the part other cycle tasks need can finish first", hub)
+	if t := tasks[idx[hub]]; len(t.InterfacesProduced) > 0 {
+		names := make([]string, 0, len(t.InterfacesProduced))
+		for _, ip := range t.InterfacesProduced {
+			names = append(names, ip.Name)
+		}
+		split += fmt.Sprintf(" (produces %s)", strings.Join(names, ", "))
+	}
+	return append(out, split)
+}
+
+func sortEdges(es []m.Edge) {
+	sort.SliceStable(es, func(i, j int) bool {
+		if es[i].From != es[j].From {
+			return es[i].From < es[j].From
+		}
+		if es[i].To != es[j].To {
+			return es[i].To < es[j].To
+		}
+		return es[i].Type < es[j].Type
+	})
+}
@@ -0,0 +1,78 @@
+package dag
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	m "github.com/james/tasks-planner/internal/model"
+)
+
+func edge(from, to, typ string, conf float64) m.Edge {
+	return m.Edge{From: from, To: to, Type: typ, IsHard: true, Confidence: conf}
+}
+
+func TestBuildReportsCycleDiagnostics(t *testing.T) {
+	tasks := []m.Task{{ID: "A"}, {ID: "B"}, {ID: "C"}, {ID: "D"}, {ID: "E"}, {ID: "F"}}
+	edges := []m.Edge{
+		edge("A", "B", "technical", 1), edge("B", "C", "sequential", 0.8), edge("C", "A", "knowledge", 0.9),
+		edge("A", "D", "technical", 1),
+		edge("D", "E", "technical", 1), edge("E", "D", "technical", 1),
+		edge("F", "F", "sequential", 1),
+	}
+	df, err := Build(tasks, edges, 0.7)
+	if !errors.Is(err, ErrCycle) {
+		t.Fatalf("err = %v, want ErrCycle", err)
+	}
+	if !strings.Contains(err.Error(), "A -> B -> C -> A") {
+		t.Fatalf("error lacks cycle path: %v", err)
+	}
+	cycles := df.Analysis.Cycles
+	if len(cycles) != 3 {
+		t.Fatalf("cycles = %+v, want 3 components", cycles)
+	}
+	abc := cycles[0]
+	if !reflect.DeepEqual(abc.Tasks, []string{"A", "B", "C"}) || !reflect.DeepEqual(abc.Cycle, []string{"A", "B", "C", "A"}) {
+		t.Fatalf("first component = %+v", abc)
+	}
+	if len(abc.Edges) != 3 {
+		t.Fatalf("edges = %+v, A->D must be excluded", abc.Edges)
+	}
+	var ranked []string
+	for _, c := range abc.BreakCandidates {
+		ranked = append(ranked, c.From+c.To)
+		if !c.BreaksCycle {
+			t.Errorf("%s->%s should break the 3-cycle alone", c.From, c.To)
+		}
+	}
+	if !reflect.DeepEqual(ranked, []string{"BC", "CA", "AB"}) {
+		t.Fatalf("ranking = %v, want lowest confidence first", ranked)
+	}
+	if len(abc.Suggestions) != 3 || !strings.Contains(abc.Suggestions[0], "B -> C") || !strings.Contains(abc.Suggestions[1], "contract task") {
+		t.Fatalf("suggestions = %v", abc.Suggestions)
+	}
+	if !reflect.DeepEqual(cycles[2].Cycle, []string{"F", "F"}) || !strings.Contains(cycles[2].Suggestions[0], "self-dependency") {
+		t.Fatalf("self-loop = %+v", cycles[2])
+	}
+	if len(df.Analysis.Errors) != 3 || df.Analysis.OK {
+		t.Fatalf("analysis = %+v", df.Analysis)
+	}
+}
+
+func TestRankBreakCandidatesPrefersEdgesThatBreakTheCycle(t *testing.T) {
+	// A<->B<->C: only edges shared by both cycles would break it alone, and none are.
+	edges := []m.Edge{
+		edge("A", "B", "technical", 0.9), edge("B", "A", "technical", 0.9),
+		edge("B", "C", "knowledge", 0.8), edge("C", "B", "sequential", 0.8),
+	}
+	got := rankBreakCandidates([]string{"A", "B", "C"}, edges)
+	if got[0].From != "B" || got[0].To != "C" || got[0].BreaksCycle {
+		t.Fatalf("top candidate = %+v, want knowledge edge B->C that does not break alone", got[0])
+	}
+	edges = append(edges[:1], edges[2:]...) // drop B->A: now A->B->C->B
+	got = rankBreakCandidates([]string{"A", "B", "C"}, edges)
+	if !got[0].BreaksCycle || got[0].Rank != 1 {
+		t.Fatalf("top candidate = %+v, want one that breaks the cycle", got[0])
+	}
+}
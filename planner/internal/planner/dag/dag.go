@@ -3,17 +3,17 @@ package dag
 import (
 	"errors"
 	"fmt"
+	"slices"
 	"sort"
 	"strings"
 
 	m "github.com/james/tasks-planner/internal/model"
+	"github.com/james/tasks-planner/internal/pert"
 )
 
-// edgeRec is a small record for edge bookkeeping during build.
-type edgeRec struct{ From, To, Type string }
-
-// Build builds a minimized DAG from tasks and edges, applying confidence and hardness filters,
-// detecting cycles, computing layering depths, longest path (critical path), and removing transitive edges.
+// Build builds a minimized DAG from tasks and edges, applying confidence and hardness filters
+// (rejected edges are listed in Analysis.SoftDeps), detecting cycles, computing layering depths,
+// the duration-weighted critical path with per-node slack, and removing transitive edges.
 func Build(tasks []m.Task, edges []m.Edge, minConfidence float64) (*m.DagFile, error) {
 	df := &m.DagFile{}
 	df.Meta.Version = "v8"
@@ -39,11 +39,12 @@ func Build(tasks []m.Task, edges []m.Edge, minConfidence float64) (*m.DagFile, e
 	}
 
 	// filter edges: structural only
-	kept := make([]edgeRec, 0, len(edges))
+	keptEdges := make([]m.Edge, 0, len(edges))
 	for _, e := range edges {
 		typeKey := edgeTypeKey(e.Type)
 		if !e.IsHard || e.Confidence < minConfidence {
 			df.Metrics.DroppedByType[typeKey]++
+			df.Analysis.SoftDeps = append(df.Analysis.SoftDeps, e)
 			continue
 		}
 		if e.Type == "resource" {
@@ -58,9 +59,12 @@ func Build(tasks []m.Task, edges []m.Edge, minConfidence float64) (*m.DagFile, e
 			df.Metrics.DroppedByType[typeKey]++
 			continue
 		}
-		kept = append(kept, edgeRec{From: e.From, To: e.To, Type: e.Type})
+		keptEdges = append(keptEdges, e)
 	}
 
+	// Soft and low-confidence edges are kept aside, with their evidence, for human review.
+	sortEdges(df.Analysis.SoftDeps)
+
 	// build adjacency
 	n := len(tasks)
 	if n == 0 {
@@ -73,7 +77,7 @@ func Build(tasks []m.Task, edges []m.Edge, minConfidence float64) (*m.DagFile, e
 	for i := range adj {
 		adj[i] = []int{}
 	}
-	for _, e := range kept {
+	for _, e := range keptEdges {
 		u, v := idx[e.From], idx[e.To]
 		adj[u] = append(adj[u], v)
 		indeg[v]++
@@ -98,8 +102,15 @@ func Build(tasks []m.Task, edges []m.Edge, minConfidence float64) (*m.DagFile, e
 	for i := 0; i < n; i++ {
 		if seen[i] == 0 && dfs(i) {
 			df.Analysis.OK = false
-			df.Analysis.Errors = append(df.Analysis.Errors, "cycle detected in dependencies")
-			return df, errors.New("cycle detected")
+			df.Analysis.Cycles = cycleDiagnostics(tasks, idx, keptEdges)
+			paths := make([]string, 0, len(df.Analysis.Cycles))
+			for _, c := range df.Analysis.Cycles {
+				path := strings.Join(c.Cycle, " -> ")
+				paths = append(paths, path)
+				df.Analysis.Errors = append(df.Analysis.Errors, fmt.Sprintf("cycle detected in dependencies: %s (%d tasks in component)", path, len(c.Tasks)))
+				df.Analysis.Warnings = append(df.Analysis.Warnings, c.Suggestions...)
+			}
+			return df, fmt.Errorf("%w: %s", ErrCycle, strings.Join(paths, "; "))
 		}
 	}
 
@@ -134,16 +145,41 @@ func Build(tasks []m.Task, edges []m.Edge, minConfidence float64) (*m.DagFile, e
 		return df, errors.New("invalid topo")
 	}
 
-	// Longest path (by edges count) and predecessor
+	// Unweighted longest path (by edge count), kept as a secondary metric.
 	dist := make([]int, n)
+	for _, u := range topo {
+		for _, v := range adj[u] {
+			if dist[v] < dist[u]+1 {
+				dist[v] = dist[u] + 1
+			}
+		}
+	}
+	longest := 0
+	for i := range dist {
+		if dist[i] > longest {
+			longest = dist[i]
+		}
+	}
+
+	// Critical path weighted by PERT expected duration. Earliest starts follow the topo order;
+	// ties prefer the predecessor on the longer chain so plans without estimates still report
+	// their longest chain.
+	dur := make([]float64, n)
+	for i, t := range tasks {
+		dur[i] = pert.Hours(t, pert.Mean(t.Duration))
+	}
+	es := make([]float64, n)
+	hops := make([]int, n)
 	pred := make([]int, n)
 	for i := range pred {
 		pred[i] = -1
 	}
 	for _, u := range topo {
 		for _, v := range adj[u] {
-			if dist[v] < dist[u]+1 {
-				dist[v] = dist[u] + 1
+			ef := es[u] + dur[u]
+			if ef > es[v] || (ef == es[v] && hops[u]+1 > hops[v]) {
+				es[v] = ef
+				hops[v] = hops[u] + 1
 				pred[v] = u
 			}
 		}
@@ -151,10 +187,12 @@ func Build(tasks []m.Task, edges []m.Edge, minConfidence float64) (*m.DagFile, e
 	// find sink on critical path
 	sink := 0
 	for i := 1; i < n; i++ {
-		if dist[i] > dist[sink] {
+		fi, fs := es[i]+dur[i], es[sink]+dur[sink]
+		if fi > fs || (fi == fs && hops[i] > hops[sink]) {
 			sink = i
 		}
 	}
+	makespan := es[sink] + dur[sink]
 	critPath := []string{}
 	for x := sink; x != -1; x = pred[x] {
 		critPath = append(critPath, tasks[x].ID)
@@ -163,39 +201,51 @@ func Build(tasks []m.Task, edges []m.Edge, minConfidence float64) (*m.DagFile, e
 	for i, j := 0, len(critPath)-1; i < j; i, j = i+1, j-1 {
 		critPath[i], critPath[j] = critPath[j], critPath[i]
 	}
-
-	// Transitive reduction: remove (u->v) if there exists u->w->...->v
-	// Compute reachability via DFS from each node.
-	reach := make([]map[int]bool, n)
-	for u := 0; u < n; u++ {
-		vis := map[int]bool{}
-		stack := []int{u}
-		for len(stack) > 0 {
-			x := stack[len(stack)-1]
-			stack = stack[:len(stack)-1]
-			for _, y := range adj[x] {
-				if !vis[y] {
-					vis[y] = true
-					stack = append(stack, y)
-				}
+	// Total float: latest start (backward pass from the makespan) minus earliest start.
+	lf := make([]float64, n)
+	for i := range lf {
+		lf[i] = makespan
+	}
+	for k := n - 1; k >= 0; k-- {
+		u := topo[k]
+		for _, v := range adj[u] {
+			if ls := lf[v] - dur[v]; ls < lf[u] {
+				lf[u] = ls
 			}
 		}
-		reach[u] = vis
 	}
-	keepEdge := make(map[[2]int]bool)
-	for _, e := range kept {
-		u, v := idx[e.From], idx[e.To]
-		removable := false
-		for _, w := range adj[u] {
-			if w != v && reach[w][v] {
-				removable = true
-				break
-			}
+	ls := make([]float64, n)
+	slack := make([]float64, n)
+	for i := range slack {
+		ls[i] = lf[i] - dur[i]
+		if s := ls[i] - es[i]; s > floatEpsilon {
+			slack[i] = s
+		} else {
+			ls[i] = es[i]
 		}
-		if !removable {
-			keepEdge[[2]int{u, v}] = true
+	}
+
+	// Transitive reduction: remove (u->v) if there exists u->w->...->v
+	reduced := transitiveReduction(adj, topo)
+
+	// Layer sizes give each node's parallel opportunity; the widest layer is WidthApprox.
+	depthCount := map[int]int{}
+	maxW := 0
+	for i := 0; i < n; i++ {
+		d := depth[i]
+		depthCount[d]++
+		if depthCount[d] > maxW {
+			maxW = depthCount[d]
 		}
 	}
+	unblocks := make([]int, n)
+	for _, e := range reduced {
+		unblocks[e[0]]++
+	}
+	onCritPath := make(map[string]bool, len(critPath))
+	for _, id := range critPath {
+		onCritPath[id] = true
+	}
 
 	// Fill nodes
 	// Stable order by ID
@@ -203,44 +253,77 @@ func Build(tasks []m.Task, edges []m.Edge, minConfidence float64) (*m.DagFile, e
 	sort.Strings(sortedIDs)
 	for _, id := range sortedIDs {
 		i := idx[id]
-		df.Nodes = append(df.Nodes, struct {
-			ID                  string `json:"id"`
-			Depth               int    `json:"depth"`
-			CriticalPath        bool   `json:"critical_path"`
-			ParallelOpportunity int    `json:"parallel_opportunity"`
-		}{ID: id, Depth: depth[i], CriticalPath: contains(critPath, id), ParallelOpportunity: 1})
+		df.Nodes = append(df.Nodes, m.DagNode{
+			ID:                  id,
+			Depth:               depth[i],
+			CriticalPath:        onCritPath[id],
+			ParallelOpportunity: depthCount[depth[i]],
+			Unblocks:            unblocks[i],
+			DurationHours:       dur[i],
+			EarliestStartHours:  es[i],
+			LatestStartHours:    ls[i],
+			SlackHours:          slack[i],
+		})
 	}
 
-	// Fill edges (non-transitive only)
+	// Fill edges (non-transitive only). Parallel edges between the same pair merge into one that
+	// carries every type, the highest confidence and the union of their evidence.
+	merged := make(map[[2]int]*m.DagEdge, len(keptEdges))
+	parallel := make(map[[2]int]int, len(keptEdges))
+	for _, e := range keptEdges {
+		key := [2]int{idx[e.From], idx[e.To]}
+		de, ok := merged[key]
+		if !ok {
+			de = &m.DagEdge{From: e.From, To: e.To, Type: e.Type, Confidence: e.Confidence}
+			merged[key] = de
+		}
+		parallel[key]++
+		if !slices.Contains(de.Types, e.Type) {
+			de.Types = append(de.Types, e.Type)
+		}
+		de.Confidence = max(de.Confidence, e.Confidence)
+		for _, ev := range e.Evidence {
+			if !slices.Contains(de.Evidence, ev) {
+				de.Evidence = append(de.Evidence, ev)
+			}
+		}
+	}
 	// Render deterministically by from,to ordering
-	type K struct {
-		F, T string
-		Ty   string
-	}
-	kept2 := []K{}
-	for key := range keepEdge {
-		u, v := key[0], key[1]
-		kept2 = append(kept2, K{F: tasks[u].ID, T: tasks[v].ID, Ty: lookupEdgeType(kept, tasks[u].ID, tasks[v].ID)})
-	}
-	sort.Slice(kept2, func(i, j int) bool {
-		if kept2[i].F == kept2[j].F {
-			return kept2[i].T < kept2[j].T
+	byIDs := func(a, b [2]int) int {
+		if c := strings.Compare(tasks[a[0]].ID, tasks[b[0]].ID); c != 0 {
+			return c
+		}
+		return strings.Compare(tasks[a[1]].ID, tasks[b[1]].ID)
+	}
+	slices.SortFunc(reduced, byIDs)
+	kept := make(map[[2]int]bool, len(reduced))
+	for _, key := range reduced {
+		df.Edges = append(df.Edges, *merged[key])
+		kept[key] = true
+	}
+	// Every merge is reported, including those whose edge the reduction then dropped.
+	var merges [][2]int
+	for key, k := range parallel {
+		if k > 1 {
+			merges = append(merges, key)
 		}
-		return kept2[i].F < kept2[j].F
-	})
-	for _, e := range kept2 {
-		df.Edges = append(df.Edges, struct {
-			From       string `json:"from"`
-			To         string `json:"to"`
-			Type       string `json:"type"`
-			Transitive bool   `json:"transitive"`
-		}{From: e.F, To: e.T, Type: e.Ty, Transitive: false})
+	}
+	slices.SortFunc(merges, byIDs)
+	for _, key := range merges {
+		de := merged[key]
+		w := fmt.Sprintf("merged %d parallel edges %s -> %s (types %s, confidence %.2f)", parallel[key], de.From, de.To, strings.Join(de.Types, ", "), de.Confidence)
+		if !kept[key] {
+			w += "; the merged edge was removed as transitive"
+		}
+		df.Analysis.Warnings = append(df.Analysis.Warnings, w)
 	}
 
-	// Recompute kept edge counts after transitive reduction.
+	// Recompute kept edge counts after transitive reduction; a merged edge counts once per type.
 	counts := map[string]int{}
 	for _, edge := range df.Edges {
-		counts[edgeTypeKey(edge.Type)]++
+		for _, typ := range edge.Types {
+			counts[edgeTypeKey(typ)]++
+		}
 	}
 	df.Metrics.KeptByType = counts
 
@@ -248,40 +331,18 @@ func Build(tasks []m.Task, edges []m.Edge, minConfidence float64) (*m.DagFile, e
 	df.Metrics.MinConfidenceApplied = minConfidence
 	df.Metrics.Nodes = n
 	df.Metrics.Edges = len(df.Edges)
-	df.Metrics.LongestPathLength = dist[sink] + 1
+	df.Metrics.LongestPathLength = longest + 1
 	df.Metrics.CriticalPath = critPath
-	// WidthApprox = max nodes per depth
-	depthCount := map[int]int{}
-	maxW := 0
-	for i := 0; i < n; i++ {
-		d := depth[i]
-		depthCount[d]++
-		if depthCount[d] > maxW {
-			maxW = depthCount[d]
-		}
-	}
+	df.Metrics.CriticalPathHours = makespan
 	df.Metrics.WidthApprox = maxW
+	qualityMetrics(df, tasks, edges)
+	df.Analysis.Warnings = append(df.Analysis.Warnings, QualityWarnings(df)...)
 	df.Analysis.OK = true
 	return df, nil
 }
 
-func contains(a []string, s string) bool {
-	for _, x := range a {
-		if x == s {
-			return true
-		}
-	}
-	return false
-}
-
-func lookupEdgeType(es []edgeRec, f, t string) string { // edgeRec is defined above
-	for _, e := range es {
-		if e.From == f && e.To == t {
-			return e.Type
-		}
-	}
-	return ""
-}
+// floatEpsilon absorbs rounding when summing durations; smaller slack is reported as zero.
+const floatEpsilon = 1e-9
 
 func edgeTypeKey(v string) string {
 	if strings.TrimSpace(v) == "" {
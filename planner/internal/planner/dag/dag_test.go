@@ -1,6 +1,7 @@
 package dag
 
 import (
+	"reflect"
 	"strings"
 	"testing"
 
@@ -55,3 +56,184 @@ func TestBuildRejectsEmptyTasks(t *testing.T) {
 		t.Fatalf("analysis errors missing empty task message: %#v", df.Analysis.Errors)
 	}
 }
+
+func timed(id string, hours float64, unit string) m.Task {
+	return m.Task{ID: id, Duration: m.DurationPERT{Optimistic: hours, MostLikely: hours, Pessimistic: hours}, DurationUnit: unit}
+}
+
+func TestBuildCriticalPathWeightedByDuration(t *testing.T) {
+	// Three 15-minute hops against one 16-hour task feeding the same sink.
+	tasks := []m.Task{
+		timed("A", 15, "minutes"), timed("B", 15, "minutes"), timed("C", 15, "minutes"),
+		timed("L", 16, "hours"), timed("Z", 1, "hours"),
+	}
+	hard := func(from, to string) m.Edge {
+		return m.Edge{From: from, To: to, Type: "technical", IsHard: true, Confidence: 1}
+	}
+	edges := []m.Edge{hard("A", "B"), hard("B", "C"), hard("C", "Z"), hard("L", "Z")}
+	df, err := Build(tasks, edges, 0.7)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if got := strings.Join(df.Metrics.CriticalPath, ","); got != "L,Z" {
+		t.Fatalf("critical path = %s, want L,Z", got)
+	}
+	if df.Metrics.CriticalPathHours != 17 {
+		t.Fatalf("critical path hours = %v, want 17", df.Metrics.CriticalPathHours)
+	}
+	if df.Metrics.LongestPathLength != 4 {
+		t.Fatalf("unweighted longest path = %d, want 4 (A,B,C,Z)", df.Metrics.LongestPathLength)
+	}
+	want := map[string]float64{"A": 15.25, "B": 15.25, "C": 15.25, "L": 0, "Z": 0}
+	for _, node := range df.Nodes {
+		if node.SlackHours != want[node.ID] {
+			t.Errorf("%s slack = %v, want %v", node.ID, node.SlackHours, want[node.ID])
+		}
+		if node.CriticalPath != (want[node.ID] == 0) {
+			t.Errorf("%s critical = %v", node.ID, node.CriticalPath)
+		}
+	}
+}
+
+func TestBuildCriticalPathWithoutEstimatesFallsBackToHops(t *testing.T) {
+	tasks := []m.Task{{ID: "A"}, {ID: "B"}, {ID: "C"}, {ID: "D"}}
+	edges := []m.Edge{
+		{From: "A", To: "B", Type: "technical", IsHard: true, Confidence: 1},
+		{From: "B", To: "C", Type: "technical", IsHard: true, Confidence: 1},
+	}
+	df, err := Build(tasks, edges, 0.7)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if got := strings.Join(df.Metrics.CriticalPath, ","); got != "A,B,C" {
+		t.Fatalf("critical path = %s, want A,B,C", got)
+	}
+	if df.Metrics.CriticalPathHours != 0 || df.Metrics.LongestPathLength != 3 {
+		t.Fatalf("metrics = %+v", df.Metrics)
+	}
+}
+
+func TestBuildNodeScheduleAndParallelism(t *testing.T) {
+	tasks := []m.Task{timed("A", 1, "hours"), timed("B", 2, "hours"), timed("C", 1, "hours"), timed("D", 1, "hours")}
+	var edges []m.Edge
+	for _, p := range [][2]string{{"A", "B"}, {"A", "C"}, {"B", "D"}, {"C", "D"}, {"A", "D"}} {
+		edges = append(edges, m.Edge{From: p[0], To: p[1], Type: "technical", IsHard: true, Confidence: 1})
+	}
+	df, err := Build(tasks, edges, 0.7)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	type sched struct {
+		parallel, unblocks int
+		es, ls, slack      float64
+	}
+	want := map[string]sched{
+		"A": {parallel: 1, unblocks: 2, es: 0, ls: 0, slack: 0},
+		"B": {parallel: 2, unblocks: 1, es: 1, ls: 1, slack: 0},
+		"C": {parallel: 2, unblocks: 1, es: 1, ls: 2, slack: 1},
+		"D": {parallel: 1, unblocks: 0, es: 3, ls: 3, slack: 0},
+	}
+	for _, node := range df.Nodes {
+		got := sched{node.ParallelOpportunity, node.Unblocks, node.EarliestStartHours, node.LatestStartHours, node.SlackHours}
+		if got != want[node.ID] {
+			t.Errorf("%s = %+v, want %+v", node.ID, got, want[node.ID])
+		}
+	}
+}
+
+func TestBuildRecordsSoftDeps(t *testing.T) {
+	tasks := []m.Task{{ID: "A"}, {ID: "B"}, {ID: "C"}}
+	ev := []m.Evidence{{Type: "doc", Source: "spec.md", Excerpt: "B should follow C"}}
+	edges := []m.Edge{
+		{From: "C", To: "B", Type: "sequential", IsHard: false, Confidence: 0.9, Evidence: ev},
+		{From: "A", To: "C", Type: "technical", IsHard: true, Confidence: 0.4},
+		{From: "A", To: "B", Type: "technical", IsHard: true, Confidence: 0.9},
+		{From: "A", To: "Z", Type: "technical", IsHard: true, Confidence: 0.9},
+	}
+	df, err := Build(tasks, edges, 0.7)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	soft := df.Analysis.SoftDeps
+	if len(soft) != 2 || soft[0].From != "A" || soft[0].To != "C" || soft[1].From != "C" {
+		t.Fatalf("soft deps = %+v", soft)
+	}
+	if len(soft[1].Evidence) != 1 || soft[1].Evidence[0].Excerpt != "B should follow C" {
+		t.Fatalf("soft dep evidence lost: %+v", soft[1])
+	}
+	if len(df.Edges) != 1 {
+		t.Fatalf("edges = %+v, want only A->B", df.Edges)
+	}
+}
+
+func TestBuildMergesParallelEdges(t *testing.T) {
+	tasks := []m.Task{{ID: "A"}, {ID: "B"}, {ID: "C"}}
+	code := m.Evidence{Type: "code", Source: "db/schema.go"}
+	infra := m.Evidence{Type: "plan", Source: "plan.md#infra", Excerpt: "B runs on A's cluster"}
+	edges := []m.Edge{
+		{From: "A", To: "B", Type: "technical", IsHard: true, Confidence: 0.8, Evidence: []m.Evidence{code}},
+		{From: "A", To: "B", Type: "infrastructure", IsHard: true, Confidence: 0.95, Evidence: []m.Evidence{infra, code}},
+		{From: "A", To: "B", Type: "technical", IsHard: true, Confidence: 0.9},
+		{From: "B", To: "C", Type: "sequential", IsHard: true, Confidence: 1},
+	}
+	df, err := Build(tasks, edges, 0.7)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if len(df.Edges) != 2 {
+		t.Fatalf("edges = %+v, want A->B and B->C", df.Edges)
+	}
+	ab := df.Edges[0]
+	if ab.Type != "technical" || !reflect.DeepEqual(ab.Types, []string{"technical", "infrastructure"}) || ab.Confidence != 0.95 {
+		t.Fatalf("merged edge = %+v", ab)
+	}
+	if !reflect.DeepEqual(ab.Evidence, []m.Evidence{code, infra}) {
+		t.Fatalf("merged evidence = %+v", ab.Evidence)
+	}
+	if bc := df.Edges[1]; !reflect.DeepEqual(bc.Types, []string{"sequential"}) || bc.Confidence != 1 {
+		t.Fatalf("single edge = %+v", bc)
+	}
+	want := map[string]int{"technical": 1, "infrastructure": 1, "sequential": 1}
+	if !reflect.DeepEqual(df.Metrics.KeptByType, want) {
+		t.Fatalf("kept by type = %v, want %v", df.Metrics.KeptByType, want)
+	}
+	found := false
+	for _, w := range df.Analysis.Warnings {
+		if strings.Contains(w, "merged 3 parallel edges A -> B (types technical, infrastructure") {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("no merge warning in %v", df.Analysis.Warnings)
+	}
+}
+
+func TestBuildReportsMergesReducedAway(t *testing.T) {
+	tasks := []m.Task{{ID: "A"}, {ID: "B"}, {ID: "C"}}
+	// The parallel A -> C pair merges, then the reduction drops it as implied by A -> B -> C.
+	edges := []m.Edge{
+		edge("B", "C", "sequential", 1), edge("B", "C", "technical", 1),
+		edge("A", "C", "technical", 1), edge("A", "C", "knowledge", 0.8),
+		edge("A", "B", "technical", 1),
+	}
+	df, err := Build(tasks, edges, 0.7)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if len(df.Edges) != 2 {
+		t.Fatalf("edges = %+v, want A->B and B->C", df.Edges)
+	}
+	var merges []string
+	for _, w := range df.Analysis.Warnings {
+		if strings.HasPrefix(w, "merged ") {
+			merges = append(merges, w)
+		}
+	}
+	want := []string{
+		"merged 2 parallel edges A -> C (types technical, knowledge, confidence 1.00); the merged edge was removed as transitive",
+		"merged 2 parallel edges B -> C (types sequential, technical, confidence 1.00)",
+	}
+	if !reflect.DeepEqual(merges, want) {
+		t.Fatalf("merge warnings = %q, want %q", merges, want)
+	}
+}
@@ -0,0 +1,130 @@
+package dag
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	m "github.com/james/tasks-planner/internal/model"
+)
+
+// Quality bands from the formal spec's definition of a passing plan.
+const (
+	MinEdgeDensity      = 0.01
+	MaxEdgeDensity      = 0.50
+	MinVerbFirstPct     = 80.0
+	MinEvidenceCoverage = 0.95
+)
+
+// qualityMetrics fills EdgeDensity, IsolatedTasks, VerbFirstPct and EvidenceCoverage. Density is
+// the reduced edge count over the n(n-1) possible directed edges; evidence coverage is the share
+// of tasks and input edges between known tasks that carry at least one evidence object.
+func qualityMetrics(df *m.DagFile, tasks []m.Task, edges []m.Edge) {
+	n := len(tasks)
+	if n > 1 {
+		df.Metrics.EdgeDensity = float64(len(df.Edges)) / float64(n*(n-1))
+	}
+	df.Metrics.IsolatedTasks = len(isolated(df))
+
+	known := make(map[string]bool, n)
+	verbFirst, evidenced, total := 0, 0, 0
+	for _, t := range tasks {
+		known[t.ID] = true
+		if VerbFirst(t.Title) {
+			verbFirst++
+		}
+		total++
+		if len(t.Evidence) > 0 {
+			evidenced++
+		}
+	}
+	for _, e := range edges {
+		if !known[e.From] || !known[e.To] {
+			continue
+		}
+		total++
+		if len(e.Evidence) > 0 {
+			evidenced++
+		}
+	}
+	if n > 0 {
+		df.Metrics.VerbFirstPct = 100 * float64(verbFirst) / float64(n)
+	}
+	if total > 0 {
+		df.Metrics.EvidenceCoverage = float64(evidenced) / float64(total)
+	}
+}
+
+// QualityWarnings reports every quality band df's metrics fall outside of. Density and isolation
+// are only judged for graphs with more than one task.
+func QualityWarnings(df *m.DagFile) []string {
+	var out []string
+	met := df.Metrics
+	if met.Nodes > 1 {
+		if met.EdgeDensity < MinEdgeDensity || met.EdgeDensity > MaxEdgeDensity {
+			out = append(out, fmt.Sprintf("edge density %.3f outside recommended band %.2f-%.2f", met.EdgeDensity, MinEdgeDensity, MaxEdgeDensity))
+		}
+		if ids := isolated(df); len(ids) > 0 {
+			out = append(out, fmt.Sprintf("%d isolated task(s) with no dependencies: %s", len(ids), strings.Join(ids, ", ")))
+		}
+	}
+	if met.VerbFirstPct < MinVerbFirstPct {
+		out = append(out, fmt.Sprintf("verb-first titles %.1f%% below %.0f%%", met.VerbFirstPct, MinVerbFirstPct))
+	}
+	if met.EvidenceCoverage < MinEvidenceCoverage {
+		out = append(out, fmt.Sprintf("evidence coverage %.1f%% below %.0f%%", 100*met.EvidenceCoverage, 100*MinEvidenceCoverage))
+	}
+	return out
+}
+
+// isolated returns the sorted IDs of nodes without any edge, or none for a single-node graph.
+func isolated(df *m.DagFile) []string {
+	if len(df.Nodes) < 2 {
+		return nil
+	}
+	linked := map[string]bool{}
+	for _, e := range df.Edges {
+		linked[e.From] = true
+		linked[e.To] = true
+	}
+	var ids []string
+	for _, node := range df.Nodes {
+		if !linked[node.ID] {
+			ids = append(ids, node.ID)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// VerbFirst reports whether title leads with an imperative verb from a fixed planning vocabulary.
+func VerbFirst(title string) bool {
+	fields := strings.FieldsFunc(title, func(r rune) bool { return !unicode.IsLetter(r) && r != '-' })
+	for _, f := range fields {
+		if f = strings.Trim(f, "-"); f != "" {
+			return leadingVerbs[strings.ToLower(f)]
+		}
+	}
+	return false
+}
+
+var leadingVerbs = func() map[string]bool {
+	out := map[string]bool{}
+	for _, v := range strings.Fields(`
+		add adopt align allow analyze apply archive audit automate backfill benchmark bootstrap build
+		bump cache capture change clean cleanup collect compile configure connect consolidate convert
+		create debug decommission define delete deploy deprecate design detect disable document
+		draft drop enable encrypt enforce ensure estimate evaluate expose extend extract fix generate
+		handle harden implement import improve index initialize inject install instrument integrate
+		introduce investigate isolate launch load log measure merge migrate model monitor move
+		normalize optimize parse patch persist plan populate port prepare profile provision prune
+		publish rebuild record refactor register release remove rename render replace report
+		research resolve restore restructure retire review rewrite roll rollout rotate run scaffold
+		scale schedule seed separate serve set setup ship simplify split stabilize standardize store
+		stub support switch sync tag test throttle trace track train transform tune update upgrade
+		validate verify wire write`) {
+		out[v] = true
+	}
+	return out
+}()
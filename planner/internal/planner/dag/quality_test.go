@@ -0,0 +1,65 @@
+package dag
+
+import (
+	"strings"
+	"testing"
+
+	m "github.com/james/tasks-planner/internal/model"
+)
+
+func TestBuildQualityMetrics(t *testing.T) {
+	ev := []m.Evidence{{Type: "doc"}}
+	tasks := []m.Task{
+		{ID: "A", Title: "Create schema", Evidence: ev},
+		{ID: "B", Title: "Migrate users", Evidence: ev},
+		{ID: "C", Title: "API handlers", Evidence: ev},
+		{ID: "D", Title: "Write docs"},
+	}
+	edges := []m.Edge{{From: "A", To: "B", Type: "technical", IsHard: true, Confidence: 1, Evidence: ev}}
+	df, err := Build(tasks, edges, 0.7)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	met := df.Metrics
+	if met.EdgeDensity != 1.0/12 || met.IsolatedTasks != 2 || met.VerbFirstPct != 75 || met.EvidenceCoverage != 0.8 {
+		t.Fatalf("metrics = %+v", met)
+	}
+	got := strings.Join(df.Analysis.Warnings, "\n")
+	for _, want := range []string{"2 isolated task(s) with no dependencies: C, D", "verb-first titles 75.0% below 80%", "evidence coverage 80.0% below 95%"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("warnings missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "edge density") {
+		t.Errorf("density %.3f is in band but warned:\n%s", met.EdgeDensity, got)
+	}
+}
+
+func TestQualityWarningsDensityBand(t *testing.T) {
+	df := &m.DagFile{}
+	df.Metrics = m.DagMetrics{Nodes: 3, EdgeDensity: 0.6, VerbFirstPct: 100, EvidenceCoverage: 1}
+	df.Nodes = []m.DagNode{{ID: "A"}, {ID: "B"}, {ID: "C"}}
+	df.Edges = []m.DagEdge{{From: "A", To: "B"}, {From: "B", To: "C"}}
+	warns := QualityWarnings(df)
+	if len(warns) != 1 || !strings.Contains(warns[0], "edge density 0.600 outside") {
+		t.Fatalf("warnings = %v", warns)
+	}
+	df.Metrics.EdgeDensity = 0.3
+	if warns := QualityWarnings(df); len(warns) != 0 {
+		t.Fatalf("warnings = %v, want none", warns)
+	}
+}
+
+func TestVerbFirst(t *testing.T) {
+	for title, want := range map[string]bool{
+		"Setup DB":         true,
+		"re-index: search": false,
+		"- Deploy service": true,
+		"API handlers":     false,
+		"":                 false,
+	} {
+		if got := VerbFirst(title); got != want {
+			t.Errorf("VerbFirst(%q) = %v, want %v", title, got, want)
+		}
+	}
+}
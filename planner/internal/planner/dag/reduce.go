@@ -0,0 +1,60 @@
+package dag
+
+// reduceBlock is the number of target nodes whose reachability is tracked per pass; it caps the
+// bitset memory at n*reduceBlock/8 bytes.
+const reduceBlock = 4096
+
+// transitiveReduction returns the edges of adj, without duplicates, that no longer path implies.
+// topo must be a topological order of adj. Reachability is kept as bitsets indexed by topo
+// position and built in reverse topo order, one block of target positions at a time: a node can
+// only reach positions after its own, so each block only needs the nodes before it.
+func transitiveReduction(adj [][]int, topo []int) [][2]int {
+	n := len(topo)
+	pos := make([]int, n)
+	for p, u := range topo {
+		pos[u] = p
+	}
+	words := (min(n, reduceBlock) + 63) / 64
+	// strict[p] holds the block positions reachable from topo[p] over at least one edge; implied is
+	// the same union taken over topo[p]'s successors, so a successor in it has a longer path.
+	strict := make([]uint64, n*words)
+	implied := make([]uint64, words)
+	var out [][2]int
+	for lo := 0; lo < n; lo += reduceBlock {
+		hi := min(lo+reduceBlock, n)
+		clear(strict)
+		for p := hi - 2; p >= 0; p-- {
+			u := topo[p]
+			row := strict[p*words : (p+1)*words]
+			clear(implied)
+			for _, v := range adj[u] {
+				q := pos[v]
+				if q >= hi {
+					continue
+				}
+				for i, w := range strict[q*words : (q+1)*words] {
+					implied[i] |= w
+				}
+				if q >= lo {
+					row[(q-lo)/64] |= 1 << ((q - lo) % 64)
+				}
+			}
+			for i, w := range implied {
+				row[i] |= w
+			}
+			for _, v := range adj[u] {
+				q := pos[v]
+				if q < lo || q >= hi {
+					continue
+				}
+				bit := uint64(1) << ((q - lo) % 64)
+				if implied[(q-lo)/64]&bit == 0 {
+					out = append(out, [2]int{u, v})
+					// Mark it so a duplicate edge to v is not emitted twice.
+					implied[(q-lo)/64] |= bit
+				}
+			}
+		}
+	}
+	return out
+}
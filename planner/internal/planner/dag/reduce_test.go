@@ -0,0 +1,126 @@
+package dag
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+
+	m "github.com/james/tasks-planner/internal/model"
+)
+
+// randomDAG links each node to up to fanout later nodes within span, in a shuffled index order so
+// topo positions differ from node indices.
+func randomDAG(rng *rand.Rand, n, fanout, span int) ([][]int, []int) {
+	perm := rng.Perm(n)
+	adj := make([][]int, n)
+	for p := 0; p < n-1; p++ {
+		for k := rng.Intn(fanout + 1); k > 0; k-- {
+			q := p + 1 + rng.Intn(min(span, n-p-1))
+			adj[perm[p]] = append(adj[perm[p]], perm[q])
+		}
+	}
+	return adj, perm
+}
+
+// naiveReduction keeps u->v unless another successor of u reaches v.
+func naiveReduction(adj [][]int, topo []int) [][2]int {
+	n := len(topo)
+	reach := make([][]bool, n)
+	for k := n - 1; k >= 0; k-- {
+		u := topo[k]
+		reach[u] = make([]bool, n)
+		for _, v := range adj[u] {
+			reach[u][v] = true
+			for x, ok := range reach[v] {
+				reach[u][x] = reach[u][x] || ok
+			}
+		}
+	}
+	seen := map[[2]int]bool{}
+	var out [][2]int
+	for u := range adj {
+		for _, v := range adj[u] {
+			implied := false
+			for _, w := range adj[u] {
+				if w != v && reach[w][v] {
+					implied = true
+				}
+			}
+			if !implied && !seen[[2]int{u, v}] {
+				seen[[2]int{u, v}] = true
+				out = append(out, [2]int{u, v})
+			}
+		}
+	}
+	return out
+}
+
+func sortedPairs(ps [][2]int) [][2]int {
+	sort.Slice(ps, func(i, j int) bool {
+		if ps[i][0] != ps[j][0] {
+			return ps[i][0] < ps[j][0]
+		}
+		return ps[i][1] < ps[j][1]
+	})
+	return ps
+}
+
+func TestTransitiveReductionMatchesNaive(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	cases := []struct{ n, fanout, span int }{
+		{2, 1, 1}, {30, 3, 5}, {200, 4, 20}, {500, 6, 500},
+		// Spans more than one reachability block.
+		{reduceBlock + 700, 3, 40},
+	}
+	for _, c := range cases {
+		adj, topo := randomDAG(rng, c.n, c.fanout, c.span)
+		got := sortedPairs(transitiveReduction(adj, topo))
+		want := sortedPairs(naiveReduction(adj, topo))
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("n=%d: reduction has %d edges, naive %d", c.n, len(got), len(want))
+		}
+	}
+}
+
+func TestTransitiveReductionDropsShortcutsAndDuplicates(t *testing.T) {
+	// 0->1->2 with shortcut 0->2 and a duplicate 1->2.
+	adj := [][]int{{1, 2}, {2, 2}, nil}
+	got := sortedPairs(transitiveReduction(adj, []int{0, 1, 2}))
+	if want := [][2]int{{0, 1}, {1, 2}}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("reduction = %v, want %v", got, want)
+	}
+}
+
+// generatedPlan builds an acyclic plan of n tasks with about three structural edges each, many of
+// them transitive, to benchmark Build at monorepo scale.
+func generatedPlan(n int) ([]m.Task, []m.Edge) {
+	rng := rand.New(rand.NewSource(42))
+	types := []string{"technical", "sequential", "infrastructure", "knowledge"}
+	tasks := make([]m.Task, n)
+	for i := range tasks {
+		tasks[i] = m.Task{ID: fmt.Sprintf("T%05d", i), Title: fmt.Sprintf("Build component %d", i), Duration: m.DurationPERT{Optimistic: 1, MostLikely: 2, Pessimistic: 4}}
+	}
+	var edges []m.Edge
+	for i := 1; i < n; i++ {
+		for k := 0; k < 3; k++ {
+			j := i - 1 - rng.Intn(min(i, 50))
+			edges = append(edges, m.Edge{From: tasks[j].ID, To: tasks[i].ID, Type: types[rng.Intn(len(types))], IsHard: true, Confidence: 0.9})
+		}
+	}
+	return tasks, edges
+}
+
+func benchmarkBuild(b *testing.B, n int) {
+	tasks, edges := generatedPlan(n)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Build(tasks, edges, 0.7); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkBuild5k(b *testing.B)  { benchmarkBuild(b, 5000) }
+func BenchmarkBuild50k(b *testing.B) { benchmarkBuild(b, 50000) }
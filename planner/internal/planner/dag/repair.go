@@ -0,0 +1,204 @@
+package dag
+
+import (
+	"fmt"
+	"sort"
+
+	m "github.com/james/tasks-planner/internal/model"
+)
+
+// MaxRepairPasses is the number of contract insertions the formal spec allows before a cycle fails
+// the plan.
+const MaxRepairPasses = 2
+
+// contractHours is the PERT estimate, in hours, of an inserted contract task.
+var contractHours = m.DurationPERT{Optimistic: 0.5, MostLikely: 1, Pessimistic: 2}
+
+// RepairCycles breaks up to maxPasses cycles that run through an interface hand-off: an edge
+// P -> C where P produces an interface C consumes. Each pass inserts a contract task that produces
+// the interface stub, drops P's edges to the interface's in-cycle consumers and makes P and those
+// consumers depend on the contract instead. Edges are filtered as in Build. The inputs are not
+// modified; repairs lists what was changed, one entry per pass, and is empty when no cycle
+// qualifies.
+func RepairCycles(tasks []m.Task, edges []m.Edge, minConfidence float64, maxPasses int) ([]m.Task, []m.Edge, []m.CycleRepair) {
+	tasks = append([]m.Task{}, tasks...)
+	edges = append([]m.Edge{}, edges...)
+	var repairs []m.CycleRepair
+	for pass := 1; pass <= maxPasses; pass++ {
+		r, ok := planRepair(tasks, edges, minConfidence)
+		if !ok {
+			break
+		}
+		r.Pass = pass
+		r.Contract = contractID(tasks)
+		tasks, edges, r = applyRepair(tasks, edges, r)
+		repairs = append(repairs, r)
+	}
+	return tasks, edges, repairs
+}
+
+// planRepair picks the interface edge to replace in the first repairable cycle, preferring edges
+// whose removal alone leaves the component acyclic, and lists the producer's in-cycle consumers of
+// that interface.
+func planRepair(tasks []m.Task, edges []m.Edge, minConfidence float64) (m.CycleRepair, bool) {
+	idx := make(map[string]int, len(tasks))
+	for i, t := range tasks {
+		idx[t.ID] = i
+	}
+	var structural []m.Edge
+	for _, e := range edges {
+		_, okFrom := idx[e.From]
+		_, okTo := idx[e.To]
+		if e.IsHard && e.Confidence >= minConfidence && e.Type != "resource" && okFrom && okTo {
+			structural = append(structural, e)
+		}
+	}
+	adj := make([][]int, len(tasks))
+	for _, e := range structural {
+		adj[idx[e.From]] = append(adj[idx[e.From]], idx[e.To])
+	}
+	comp := tarjan(adj)
+
+	members := map[int][]string{}
+	for v, c := range comp {
+		members[c] = append(members[c], tasks[v].ID)
+	}
+	inner := map[int][]m.Edge{}
+	for _, e := range structural {
+		if c := comp[idx[e.From]]; c == comp[idx[e.To]] && e.From != e.To {
+			inner[c] = append(inner[c], e)
+		}
+	}
+	comps := make([]int, 0, len(inner))
+	for c, ids := range members {
+		sort.Strings(ids)
+		if len(ids) > 1 {
+			comps = append(comps, c)
+		}
+	}
+	sort.Slice(comps, func(i, j int) bool { return members[comps[i]][0] < members[comps[j]][0] })
+
+	for _, c := range comps {
+		es := inner[c]
+		sortEdges(es)
+		best, bestBreaks := -1, false
+		var iface string
+		for i, e := range es {
+			name := handoff(tasks[idx[e.From]], tasks[idx[e.To]])
+			if name == "" {
+				continue
+			}
+			breaks := len(es) <= breakCheckLimit && acyclicWithout(members[c], es, i)
+			if best < 0 || (breaks && !bestBreaks) {
+				best, bestBreaks, iface = i, breaks, name
+			}
+		}
+		if best < 0 {
+			continue
+		}
+		r := m.CycleRepair{Interface: iface, Producer: es[best].From}
+		for _, e := range es {
+			if e.From == r.Producer && consumes(tasks[idx[e.To]], iface) {
+				if len(r.Consumers) == 0 || r.Consumers[len(r.Consumers)-1] != e.To {
+					r.Consumers = append(r.Consumers, e.To)
+				}
+			}
+		}
+		return r, true
+	}
+	return m.CycleRepair{}, false
+}
+
+// applyRepair appends the contract task and replaces every producer -> consumer edge, recorded in
+// r.Removed, with edges from the contract.
+func applyRepair(tasks []m.Task, edges []m.Edge, r m.CycleRepair) ([]m.Task, []m.Edge, m.CycleRepair) {
+	var producer m.Task
+	for _, t := range tasks {
+		if t.ID == r.Producer {
+			producer = t
+		}
+	}
+	stub := m.InterfaceProduced{Name: r.Interface, Version: "stub"}
+	for _, ip := range producer.InterfacesProduced {
+		if ip.Name == r.Interface {
+			stub.Type = ip.Type
+		}
+	}
+	contract := m.Task{
+		ID:                 r.Contract,
+		FeatureID:          producer.FeatureID,
+		Title:              fmt.Sprintf("Define %s contract", r.Interface),
+		Description:        fmt.Sprintf("Inserted to break a dependency cycle: publishes the %s interface stub that %s implements and its consumers build against.", r.Interface, r.Producer),
+		Duration:           contractHours,
+		DurationUnit:       "hours",
+		InterfacesProduced: []m.InterfaceProduced{stub},
+		AcceptanceChecks:   []m.AcceptanceCheck{{Type: "command", Cmd: "echo ok", Timeout: 5}},
+		Evidence:           []m.Evidence{repairEvidence(r)},
+	}
+	contract.ExecutionLogging = producer.ExecutionLogging
+	contract.Compensation.Idempotent = true
+	tasks = append(tasks, contract)
+
+	consumer := map[string]bool{}
+	for _, id := range r.Consumers {
+		consumer[id] = true
+	}
+	out := make([]m.Edge, 0, len(edges)+len(r.Consumers)+1)
+	for _, e := range edges {
+		if e.From == r.Producer && consumer[e.To] {
+			r.Removed = append(r.Removed, e)
+			continue
+		}
+		out = append(out, e)
+	}
+	for _, to := range append([]string{r.Producer}, r.Consumers...) {
+		out = append(out, m.Edge{From: r.Contract, To: to, Type: "technical", IsHard: true, Confidence: 1, Evidence: []m.Evidence{repairEvidence(r)}})
+	}
+	return tasks, out, r
+}
+
+func repairEvidence(r m.CycleRepair) m.Evidence {
+	return m.Evidence{
+		Type:       "plan",
+		Source:     "autonormalization/cycle_repairs",
+		Confidence: 1,
+		Rationale:  fmt.Sprintf("pass %d: contract for %s replaces %s's direct edges to its consumers", r.Pass, r.Interface, r.Producer),
+	}
+}
+
+// handoff returns the first interface, by name, that from produces and to consumes.
+func handoff(from, to m.Task) string {
+	var names []string
+	for _, ip := range from.InterfacesProduced {
+		if consumes(to, ip.Name) {
+			names = append(names, ip.Name)
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	sort.Strings(names)
+	return names[0]
+}
+
+func consumes(t m.Task, name string) bool {
+	for _, ic := range t.InterfacesConsumed {
+		if ic.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// contractID returns the first free ID of the form C001, C002, ...
+func contractID(tasks []m.Task) string {
+	used := make(map[string]bool, len(tasks))
+	for _, t := range tasks {
+		used[t.ID] = true
+	}
+	for i := 1; ; i++ {
+		if id := fmt.Sprintf("C%03d", i); !used[id] {
+			return id
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package dag
+
+import (
+	"reflect"
+	"testing"
+
+	m "github.com/james/tasks-planner/internal/model"
+)
+
+func producer(id string, ifaces ...string) m.Task {
+	t := m.Task{ID: id, FeatureID: "F1"}
+	for _, name := range ifaces {
+		t.InterfacesProduced = append(t.InterfacesProduced, m.InterfaceProduced{Name: name, Version: "v1", Type: "http"})
+	}
+	return t
+}
+
+func consumer(t m.Task, ifaces ...string) m.Task {
+	for _, name := range ifaces {
+		t.InterfacesConsumed = append(t.InterfacesConsumed, m.InterfaceConsumed{Name: name, Required: true})
+	}
+	return t
+}
+
+func TestRepairCyclesInsertsContract(t *testing.T) {
+	tasks := []m.Task{producer("A", "users-api"), consumer(m.Task{ID: "B"}, "users-api"), {ID: "C"}}
+	edges := []m.Edge{edge("A", "B", "technical", 1), edge("B", "C", "sequential", 1), edge("C", "A", "sequential", 1)}
+	gotTasks, gotEdges, repairs := RepairCycles(tasks, edges, 0.7, MaxRepairPasses)
+
+	if len(repairs) != 1 {
+		t.Fatalf("repairs = %+v, want 1", repairs)
+	}
+	r := repairs[0]
+	if r.Pass != 1 || r.Contract != "C001" || r.Interface != "users-api" || r.Producer != "A" || !reflect.DeepEqual(r.Consumers, []string{"B"}) {
+		t.Fatalf("repair = %+v", r)
+	}
+	if len(r.Removed) != 1 || r.Removed[0].From != "A" || r.Removed[0].To != "B" {
+		t.Fatalf("removed = %+v", r.Removed)
+	}
+	if len(tasks) != 3 || len(edges) != 3 {
+		t.Fatal("inputs were modified")
+	}
+	if len(gotTasks) != 4 {
+		t.Fatalf("tasks = %+v", gotTasks)
+	}
+	c := gotTasks[3]
+	if c.ID != "C001" || c.FeatureID != "F1" || len(c.AcceptanceChecks) == 0 || !c.Compensation.Idempotent {
+		t.Fatalf("contract = %+v", c)
+	}
+	if want := []m.InterfaceProduced{{Name: "users-api", Version: "stub", Type: "http"}}; !reflect.DeepEqual(c.InterfacesProduced, want) {
+		t.Fatalf("contract interfaces = %+v", c.InterfacesProduced)
+	}
+	var pairs []string
+	for _, e := range gotEdges {
+		pairs = append(pairs, e.From+">"+e.To)
+	}
+	if want := []string{"B>C", "C>A", "C001>A", "C001>B"}; !reflect.DeepEqual(pairs, want) {
+		t.Fatalf("edges = %v, want %v", pairs, want)
+	}
+	if _, err := Build(gotTasks, gotEdges, 0.7); err != nil {
+		t.Fatalf("repaired graph still fails: %v", err)
+	}
+}
+
+func TestRepairCyclesStopsAfterMaxPasses(t *testing.T) {
+	tasks := []m.Task{
+		producer("A", "a-api"), consumer(m.Task{ID: "B"}, "a-api"),
+		producer("D", "d-api"), consumer(m.Task{ID: "E"}, "d-api"),
+		producer("G", "g-api"), consumer(m.Task{ID: "H"}, "g-api"),
+	}
+	edges := []m.Edge{
+		edge("A", "B", "technical", 1), edge("B", "A", "technical", 1),
+		edge("D", "E", "technical", 1), edge("E", "D", "technical", 1),
+		edge("G", "H", "technical", 1), edge("H", "G", "technical", 1),
+	}
+	gotTasks, gotEdges, repairs := RepairCycles(tasks, edges, 0.7, MaxRepairPasses)
+	if len(repairs) != 2 || repairs[0].Producer != "A" || repairs[1].Producer != "D" || repairs[1].Contract != "C002" || repairs[1].Pass != 2 {
+		t.Fatalf("repairs = %+v", repairs)
+	}
+	df, err := Build(gotTasks, gotEdges, 0.7)
+	if err == nil || len(df.Analysis.Cycles) != 1 || df.Analysis.Cycles[0].Tasks[0] != "G" {
+		t.Fatalf("want the third cycle left for diagnostics, err = %v", err)
+	}
+}
+
+func TestRepairCyclesSkipsPlainCycles(t *testing.T) {
+	tasks := []m.Task{producer("A", "users-api"), {ID: "B"}, consumer(m.Task{ID: "C"}, "users-api")}
+	edges := []m.Edge{
+		edge("A", "B", "technical", 1), edge("B", "A", "technical", 1),
+		// Low-confidence edges do not form structural cycles.
+		{From: "A", To: "C", Type: "technical", IsHard: true, Confidence: 0.5},
+		{From: "C", To: "A", Type: "technical", IsHard: true, Confidence: 0.5},
+	}
+	gotTasks, gotEdges, repairs := RepairCycles(tasks, edges, 0.7, MaxRepairPasses)
+	if len(repairs) != 0 || !reflect.DeepEqual(gotTasks, tasks) || !reflect.DeepEqual(gotEdges, edges) {
+		t.Fatalf("unexpected repair %+v", repairs)
+	}
+}
@@ -23,6 +23,10 @@ type TaskSpec struct {
     After     []string  // dependencies by title or ID
     Hours     float64   // duration hint in hours (0 if unset)
     Accept    []m.AcceptanceCheck
+    Rollback  string    // compensation command from a 'rollback:' line (empty if unset)
+    Caps      []string  // worker capabilities from a 'capabilities:' line
+    Produces  []string  // interface names from a 'produces:' line
+    Consumes  []string  // interface names from a 'consumes:' line
     Errors    []string
 }
 
@@ -31,6 +35,11 @@ var (
     reTask    = regexp.MustCompile(`^\s*[-*]\s+(?:\[.?\]\s*)?(.+?)\s*$`) // '- task title' or '- [ ] task'
     reAfter   = regexp.MustCompile(`(?i)\bafter\s*:\s*([^;]+)$`)             // 'after: A, B, T001'
     reDur     = regexp.MustCompile(`\((\d+(?:\.\d+)?)(h|m)\)`)             // '(3h)' or '(90m)'
+    // Task attributes sit on their own line under a task, either as a plain continuation line or
+    // an indented sub-item; a top-level '- Rollback: old schema' is a task title, not an attribute.
+    reRollback = regexp.MustCompile(`(?i)^(?:\s+[-*]\s+|\s*)rollback\s*:\s*(.*?)\s*$`) // 'rollback: cmd'
+    reCaps     = regexp.MustCompile(`(?i)^(?:\s+[-*]\s+|\s*)(?:capabilities|caps)\s*:\s*(.*?)\s*$`) // 'capabilities: go, docker'
+    reIface    = regexp.MustCompile(`(?i)^(?:\s+[-*]\s+|\s*)(produces|consumes)\s*:\s*(.*?)\s*$`) // 'produces: users-api'
 )
 
 // ParseMarkdown extracts features (## headings) and tasks (bullet items under last feature).
@@ -84,6 +93,36 @@ func ParseMarkdown(input string) (features []Feature, tasks []TaskSpec) {
             fenceBuf = append(fenceBuf, line)
             continue
         }
+        if rm := reRollback.FindStringSubmatch(line); rm != nil && lastTaskIdx >= 0 {
+            if rm[1] == "" {
+                tasks[lastTaskIdx].Errors = append(tasks[lastTaskIdx].Errors, "rollback: missing command")
+            } else {
+                tasks[lastTaskIdx].Rollback = rm[1]
+            }
+            continue
+        }
+        if cm := reCaps.FindStringSubmatch(line); cm != nil && lastTaskIdx >= 0 {
+            for _, c := range strings.Split(cm[1], ",") {
+                c = strings.ToLower(strings.TrimSpace(c))
+                if c != "" && !contains(tasks[lastTaskIdx].Caps, c) { tasks[lastTaskIdx].Caps = append(tasks[lastTaskIdx].Caps, c) }
+            }
+            if len(tasks[lastTaskIdx].Caps) == 0 {
+                tasks[lastTaskIdx].Errors = append(tasks[lastTaskIdx].Errors, "capabilities: none listed")
+            }
+            continue
+        }
+        if im := reIface.FindStringSubmatch(line); im != nil && lastTaskIdx >= 0 {
+            list := &tasks[lastTaskIdx].Produces
+            if strings.EqualFold(im[1], "consumes") { list = &tasks[lastTaskIdx].Consumes }
+            for _, name := range strings.Split(im[2], ",") {
+                name = strings.TrimSpace(name)
+                if name != "" && !contains(*list, name) { *list = append(*list, name) }
+            }
+            if len(*list) == 0 {
+                tasks[lastTaskIdx].Errors = append(tasks[lastTaskIdx].Errors, strings.ToLower(im[1])+": no interfaces listed")
+            }
+            continue
+        }
         if m := reFeature.FindStringSubmatch(line); m != nil {
             featureCount++
             id := formatID("F", featureCount)
@@ -130,6 +169,13 @@ func ParseMarkdown(input string) (features []Feature, tasks []TaskSpec) {
     return features, tasks
 }
 
+func contains(list []string, v string) bool {
+    for _, s := range list {
+        if s == v { return true }
+    }
+    return false
+}
+
 func formatID(prefix string, n int) string {
     return fmt.Sprintf("%s%03d", prefix, n)
 }
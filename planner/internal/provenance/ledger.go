@@ -0,0 +1,282 @@
+package provenance
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/james/tasks-planner/internal/canonjson"
+	"github.com/james/tasks-planner/internal/hash"
+)
+
+// Record kinds written by the executor.
+const (
+	KindDispatch = "dispatch"
+	KindComplete = "complete"
+	KindFail     = "fail"
+	KindPatch    = "patch"
+	KindRollback = "rollback"
+	KindRequeue  = "requeue"
+	KindRetry    = "retry"
+	KindAdmin    = "admin"
+)
+
+var (
+	// ErrTampered reports a record whose hash, chain link, or sequence number does not match.
+	ErrTampered = errors.New("provenance ledger tampered")
+	// ErrTruncated reports a ledger cut mid-record or shorter than the expected head.
+	ErrTruncated = errors.New("provenance ledger truncated")
+)
+
+// ArtifactChange captures a file touched by a task with its content hash before and after.
+// An empty hash means the file did not exist.
+type ArtifactChange struct {
+	Path   string `json:"path"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// Record is one ledger line. Hash covers the canonical JSON of the record with Hash blank, and
+// PrevHash links it to the preceding record so any edit or deletion breaks the chain.
+type Record struct {
+	Seq          int64            `json:"seq"`
+	Kind         string           `json:"kind"`
+	Time         string           `json:"time"`
+	TaskID       string           `json:"task_id,omitempty"`
+	StartedAt    string           `json:"started_at,omitempty"`
+	FinishedAt   string           `json:"finished_at,omitempty"`
+	ExitCode     *int             `json:"exit_code,omitempty"`
+	WorkerID     string           `json:"worker_id,omitempty"`
+	Artifacts    []ArtifactChange `json:"artifacts,omitempty"`
+	Telemetry    string           `json:"telemetry,omitempty"`
+	CheckpointID string           `json:"checkpoint_id,omitempty"`
+	Error        string           `json:"error,omitempty"`
+	Data         map[string]any   `json:"data,omitempty"`
+	PrevHash     string           `json:"prev_hash"`
+	Hash         string           `json:"hash"`
+}
+
+// computeHash returns the SHA-256 of the record's canonical JSON with Hash blank.
+func computeHash(rec Record) (string, error) {
+	rec.Hash = ""
+	raw, err := json.Marshal(rec)
+	if err != nil {
+		return "", err
+	}
+	can, err := canonjson.ToCanonicalJSON(raw)
+	if err != nil {
+		return "", err
+	}
+	return hash.HashCanonicalBytes(can), nil
+}
+
+// Ledger appends hash-chained records to a JSONL file, fsyncing after every write.
+// It is safe for concurrent use.
+type Ledger struct {
+	mu   sync.Mutex
+	f    *os.File
+	path string
+	seq  int64
+	head string
+}
+
+// Open opens (or creates) the ledger at path. An existing ledger is verified first so new records
+// never extend a broken chain.
+func Open(path string) (*Ledger, error) {
+	res, err := VerifyFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("open ledger %s: %w", path, err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open ledger %s: %w", path, err)
+	}
+	return &Ledger{f: f, path: path, seq: int64(res.Records), head: res.Head}, nil
+}
+
+// Path returns the ledger file path.
+func (l *Ledger) Path() string { return l.path }
+
+// Head returns the hash of the last record written (empty for an empty ledger).
+func (l *Ledger) Head() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.head
+}
+
+// Append assigns sequence, chain link and hash to rec, writes it, and fsyncs the file.
+func (l *Ledger) Append(rec Record) (Record, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.f == nil {
+		return Record{}, errors.New("ledger closed")
+	}
+	rec.Seq = l.seq + 1
+	rec.PrevHash = l.head
+	h, err := computeHash(rec)
+	if err != nil {
+		return Record{}, fmt.Errorf("hash record: %w", err)
+	}
+	rec.Hash = h
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return Record{}, fmt.Errorf("encode record: %w", err)
+	}
+	line = append(line, '\n')
+	if _, err := l.f.Write(line); err != nil {
+		return Record{}, fmt.Errorf("append ledger: %w", err)
+	}
+	if err := l.f.Sync(); err != nil {
+		return Record{}, fmt.Errorf("sync ledger: %w", err)
+	}
+	l.seq = rec.Seq
+	l.head = rec.Hash
+	return rec, nil
+}
+
+// Query selects ledger records; zero fields match everything. Since and Until bound the record
+// time inclusively, and Limit keeps only the most recent matches.
+type Query struct {
+	TaskID string
+	Kind   string
+	Since  time.Time
+	Until  time.Time
+	Limit  int
+}
+
+// Match reports whether rec satisfies q. Records with an unparseable time never match a time bound.
+func (q Query) Match(rec Record) bool {
+	if q.TaskID != "" && rec.TaskID != q.TaskID {
+		return false
+	}
+	if q.Kind != "" && rec.Kind != q.Kind {
+		return false
+	}
+	if q.Since.IsZero() && q.Until.IsZero() {
+		return true
+	}
+	t, err := time.Parse(time.RFC3339Nano, rec.Time)
+	if err != nil {
+		return false
+	}
+	return (q.Since.IsZero() || !t.Before(q.Since)) && (q.Until.IsZero() || !t.After(q.Until))
+}
+
+// Records returns the records written so far that match q, in ledger order.
+func (l *Ledger) Records(q Query) ([]Record, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	f, err := os.Open(l.path)
+	if err != nil {
+		return nil, fmt.Errorf("read ledger: %w", err)
+	}
+	defer f.Close()
+	var out []Record
+	err = scan(f, func(rec Record, _ int) error {
+		if q.Match(rec) {
+			out = append(out, rec)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if q.Limit > 0 && len(out) > q.Limit {
+		out = out[len(out)-q.Limit:]
+	}
+	return out, nil
+}
+
+// Close closes the underlying file.
+func (l *Ledger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.f == nil {
+		return nil
+	}
+	err := l.f.Close()
+	l.f = nil
+	return err
+}
+
+// VerifyResult summarizes a verified ledger.
+type VerifyResult struct {
+	Records int
+	Head    string
+}
+
+// VerifyFile verifies the ledger at path.
+func VerifyFile(path string) (VerifyResult, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return VerifyResult{}, err
+	}
+	defer f.Close()
+	return Verify(f)
+}
+
+// Verify walks every record checking sequence numbers, chain links and hashes. A final line
+// without a trailing newline is reported as truncation.
+func Verify(r io.Reader) (VerifyResult, error) {
+	var res VerifyResult
+	err := scan(r, func(rec Record, line int) error {
+		if rec.Seq != int64(res.Records+1) {
+			return fmt.Errorf("%w: line %d has seq %d, want %d", ErrTampered, line, rec.Seq, res.Records+1)
+		}
+		if rec.PrevHash != res.Head {
+			return fmt.Errorf("%w: line %d prev_hash does not match preceding record", ErrTampered, line)
+		}
+		h, err := computeHash(rec)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		if h != rec.Hash {
+			return fmt.Errorf("%w: line %d hash mismatch", ErrTampered, line)
+		}
+		res.Records++
+		res.Head = rec.Hash
+		return nil
+	})
+	return res, err
+}
+
+// ReadAll returns every record without verifying the chain.
+func ReadAll(r io.Reader) ([]Record, error) {
+	var out []Record
+	err := scan(r, func(rec Record, _ int) error {
+		out = append(out, rec)
+		return nil
+	})
+	return out, err
+}
+
+func scan(r io.Reader, fn func(rec Record, line int) error) error {
+	br := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		raw, err := br.ReadBytes('\n')
+		if err == io.EOF {
+			if len(bytes.TrimSpace(raw)) > 0 {
+				return fmt.Errorf("%w: line %d is incomplete", ErrTruncated, line)
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var rec Record
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&rec); err != nil {
+			return fmt.Errorf("%w: line %d: %v", ErrTampered, line, err)
+		}
+		if err := fn(rec, line); err != nil {
+			return err
+		}
+	}
+}
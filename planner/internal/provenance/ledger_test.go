@@ -0,0 +1,125 @@
+package provenance
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeLedger(t *testing.T, n int) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "provenance.jsonl")
+	l, err := Open(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	for i := 0; i < n; i++ {
+		code := i
+		if _, err := l.Append(Record{Kind: KindComplete, Time: "2026-01-01T00:00:00Z", TaskID: "T001", ExitCode: &code}); err != nil {
+			t.Fatalf("append: %v", err)
+		}
+	}
+	if err := l.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	return path
+}
+
+func TestLedgerAppendAndVerify(t *testing.T) {
+	path := writeLedger(t, 3)
+	res, err := VerifyFile(path)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if res.Records != 3 || len(res.Head) != 64 {
+		t.Fatalf("unexpected result %+v", res)
+	}
+
+	// Reopening continues the chain.
+	l, err := Open(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	rec, err := l.Append(Record{Kind: KindDispatch, Time: "2026-01-01T00:00:01Z", TaskID: "T002"})
+	if err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	_ = l.Close()
+	if rec.Seq != 4 || rec.PrevHash != res.Head {
+		t.Fatalf("chain not continued: %+v", rec)
+	}
+	if res, err := VerifyFile(path); err != nil || res.Records != 4 {
+		t.Fatalf("verify after reopen: %+v %v", res, err)
+	}
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	path := writeLedger(t, 3)
+	data, _ := os.ReadFile(path)
+	lines := strings.SplitAfter(string(data), "\n")
+
+	edited := strings.Replace(string(data), `"task_id":"T001"`, `"task_id":"T999"`, 1)
+	if _, err := Verify(strings.NewReader(edited)); !errors.Is(err, ErrTampered) {
+		t.Fatalf("edit: expected ErrTampered, got %v", err)
+	}
+	dropped := lines[0] + lines[2]
+	if _, err := Verify(strings.NewReader(dropped)); !errors.Is(err, ErrTampered) {
+		t.Fatalf("deletion: expected ErrTampered, got %v", err)
+	}
+	cut := string(data[:len(data)-10])
+	if _, err := Verify(strings.NewReader(cut)); !errors.Is(err, ErrTruncated) {
+		t.Fatalf("cut: expected ErrTruncated, got %v", err)
+	}
+	if err := os.WriteFile(path, []byte(cut), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if _, err := Open(path); !errors.Is(err, ErrTruncated) {
+		t.Fatalf("open must refuse a truncated ledger, got %v", err)
+	}
+}
+
+func TestReadAll(t *testing.T) {
+	path := writeLedger(t, 2)
+	data, _ := os.ReadFile(path)
+	recs, err := ReadAll(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if len(recs) != 2 || recs[1].ExitCode == nil || *recs[1].ExitCode != 1 {
+		t.Fatalf("unexpected records %+v", recs)
+	}
+}
+
+func TestLedgerRecordsQuery(t *testing.T) {
+	l, err := Open(filepath.Join(t.TempDir(), "provenance.jsonl"))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer l.Close()
+	for _, rec := range []Record{
+		{Kind: KindDispatch, Time: "2026-01-01T00:00:00Z", TaskID: "A"},
+		{Kind: KindComplete, Time: "2026-01-01T00:05:00Z", TaskID: "A"},
+		{Kind: KindDispatch, Time: "2026-01-01T00:06:00Z", TaskID: "B"},
+		{Kind: KindFail, Time: "2026-01-01T00:09:00Z", TaskID: "B"},
+	} {
+		if _, err := l.Append(rec); err != nil {
+			t.Fatalf("append: %v", err)
+		}
+	}
+	got, err := l.Records(Query{TaskID: "A"})
+	if err != nil || len(got) != 2 {
+		t.Fatalf("expected 2 records for A, got %d (%v)", len(got), err)
+	}
+	got, _ = l.Records(Query{Since: time.Date(2026, 1, 1, 0, 5, 0, 0, time.UTC), Until: time.Date(2026, 1, 1, 0, 6, 0, 0, time.UTC)})
+	if len(got) != 2 || got[0].Seq != 2 || got[1].Seq != 3 {
+		t.Fatalf("unexpected time window %+v", got)
+	}
+	got, _ = l.Records(Query{Kind: KindDispatch, Limit: 1})
+	if len(got) != 1 || got[0].TaskID != "B" {
+		t.Fatalf("expected the latest dispatch, got %+v", got)
+	}
+}
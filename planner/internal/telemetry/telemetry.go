@@ -0,0 +1,167 @@
+package telemetry
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Status values allowed by the standard telemetry contract (formal-spec §9).
+const (
+	StatusStart    = "start"
+	StatusProgress = "progress"
+	StatusDone     = "done"
+	StatusError    = "error"
+)
+
+// DefaultRequiredFields mirrors the planner's default Task.ExecutionLogging.RequiredFields.
+var DefaultRequiredFields = []string{"timestamp", "task_id", "step", "status", "message"}
+
+// Line is one decoded telemetry record.
+type Line struct {
+	Timestamp string         `json:"timestamp"`
+	TaskID    string         `json:"task_id"`
+	Step      string         `json:"step"`
+	Status    string         `json:"status"`
+	Message   string         `json:"message"`
+	Progress  *float64       `json:"progress,omitempty"`
+	Data      map[string]any `json:"data,omitempty"`
+}
+
+// ErrorCode returns data.error_code for error lines (empty when absent).
+func (l Line) ErrorCode() string {
+	if l.Data == nil {
+		return ""
+	}
+	switch v := l.Data["error_code"].(type) {
+	case string:
+		return v
+	case float64:
+		return fmt.Sprintf("%g", v)
+	}
+	return ""
+}
+
+// Violation describes a line that does not honor the contract.
+type Violation struct {
+	Line   int    `json:"line"`
+	Reason string `json:"reason"`
+	Raw    string `json:"raw,omitempty"`
+}
+
+// Summary aggregates a consumed stream.
+type Summary struct {
+	Lines      int
+	Violations []Violation
+	Progress   float64
+	LastStatus string
+}
+
+// Conforming reports whether every line honored the contract.
+func (s Summary) Conforming() bool { return len(s.Violations) == 0 }
+
+// Reader validates a worker's JSONL stream line by line.
+type Reader struct {
+	// TaskID, when set, must match every line's task_id.
+	TaskID string
+	// RequiredFields defaults to DefaultRequiredFields.
+	RequiredFields []string
+	OnLine         func(Line)
+	OnProgress     func(pct float64)
+	OnViolation    func(Violation)
+}
+
+// Consume reads r until EOF. Valid lines reach OnLine; progress percentages (0-100) reach
+// OnProgress; every contract breach is reported to OnViolation and collected in the Summary.
+func (rd Reader) Consume(r io.Reader) (Summary, error) {
+	var sum Summary
+	required := rd.RequiredFields
+	if len(required) == 0 {
+		required = DefaultRequiredFields
+	}
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 2*1024*1024)
+	n := 0
+	for sc.Scan() {
+		n++
+		raw := bytes.TrimSpace(sc.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+		sum.Lines++
+		line, reasons := rd.parse(raw, required)
+		if len(reasons) > 0 {
+			v := Violation{Line: n, Reason: strings.Join(reasons, "; "), Raw: truncate(string(raw), 256)}
+			sum.Violations = append(sum.Violations, v)
+			if rd.OnViolation != nil {
+				rd.OnViolation(v)
+			}
+			continue
+		}
+		sum.LastStatus = line.Status
+		if line.Progress != nil {
+			sum.Progress = *line.Progress
+			if rd.OnProgress != nil {
+				rd.OnProgress(*line.Progress)
+			}
+		} else if line.Status == StatusDone {
+			sum.Progress = 100
+			if rd.OnProgress != nil {
+				rd.OnProgress(100)
+			}
+		}
+		if rd.OnLine != nil {
+			rd.OnLine(line)
+		}
+	}
+	return sum, sc.Err()
+}
+
+func (rd Reader) parse(raw []byte, required []string) (Line, []string) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return Line{}, []string{"not a JSON object"}
+	}
+	var line Line
+	if err := json.Unmarshal(raw, &line); err != nil {
+		return Line{}, []string{"invalid field types: " + err.Error()}
+	}
+	var reasons []string
+	var missing []string
+	for _, f := range required {
+		v, ok := fields[f]
+		if !ok || string(v) == "null" || string(v) == `""` {
+			missing = append(missing, f)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		reasons = append(reasons, "missing required fields: "+strings.Join(missing, ", "))
+	}
+	switch line.Status {
+	case StatusStart, StatusProgress, StatusDone, StatusError:
+	default:
+		reasons = append(reasons, fmt.Sprintf("invalid status %q", line.Status))
+	}
+	if rd.TaskID != "" && line.TaskID != "" && line.TaskID != rd.TaskID {
+		reasons = append(reasons, fmt.Sprintf("task_id %q does not match %q", line.TaskID, rd.TaskID))
+	}
+	if line.Progress != nil && (*line.Progress < 0 || *line.Progress > 100) {
+		reasons = append(reasons, fmt.Sprintf("progress %g outside 0-100", *line.Progress))
+	}
+	if line.Status == StatusError && line.ErrorCode() == "" {
+		reasons = append(reasons, "error line missing data.error_code")
+	}
+	return line, reasons
+}
+
+func truncate(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	return s[:limit] + "…"
+}
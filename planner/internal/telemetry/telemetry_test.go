@@ -0,0 +1,72 @@
+package telemetry
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReaderValidatesStream(t *testing.T) {
+	stream := strings.Join([]string{
+		`{"timestamp":"2026-01-01T00:00:00Z","task_id":"T001","step":"build","status":"start","message":"go"}`,
+		`{"timestamp":"2026-01-01T00:00:01Z","task_id":"T001","step":"build","status":"progress","message":"half","progress":50}`,
+		`plain prose from a chatty worker`,
+		`{"timestamp":"2026-01-01T00:00:02Z","task_id":"T001","status":"progress","message":"no step"}`,
+		`{"timestamp":"2026-01-01T00:00:03Z","task_id":"T001","step":"build","status":"finished","message":"?"}`,
+		`{"timestamp":"2026-01-01T00:00:04Z","task_id":"T002","step":"build","status":"progress","message":"wrong task"}`,
+		`{"timestamp":"2026-01-01T00:00:05Z","task_id":"T001","step":"build","status":"error","message":"no code"}`,
+		`{"timestamp":"2026-01-01T00:00:06Z","task_id":"T001","step":"build","status":"error","message":"boom","data":{"error_code":"E_MODULE"}}`,
+		``,
+		`{"timestamp":"2026-01-01T00:00:07Z","task_id":"T001","step":"build","status":"done","message":"ok"}`,
+	}, "\n")
+
+	var progress []float64
+	var codes []string
+	rd := Reader{
+		TaskID:     "T001",
+		OnProgress: func(p float64) { progress = append(progress, p) },
+		OnLine: func(l Line) {
+			if l.Status == StatusError {
+				codes = append(codes, l.ErrorCode())
+			}
+		},
+	}
+	sum, err := rd.Consume(strings.NewReader(stream))
+	if err != nil {
+		t.Fatalf("consume: %v", err)
+	}
+	if sum.Lines != 9 {
+		t.Fatalf("expected 9 non-empty lines, got %d", sum.Lines)
+	}
+	wantReasons := []string{"not a JSON object", "missing required fields: step", `invalid status "finished"`, "does not match", "missing data.error_code"}
+	if len(sum.Violations) != len(wantReasons) {
+		t.Fatalf("expected %d violations, got %+v", len(wantReasons), sum.Violations)
+	}
+	for i, want := range wantReasons {
+		if !strings.Contains(sum.Violations[i].Reason, want) {
+			t.Fatalf("violation %d: %q does not contain %q", i, sum.Violations[i].Reason, want)
+		}
+	}
+	if sum.Conforming() {
+		t.Fatalf("stream should be flagged non-conforming")
+	}
+	if len(progress) != 2 || progress[0] != 50 || progress[1] != 100 {
+		t.Fatalf("unexpected progress %v", progress)
+	}
+	if len(codes) != 1 || codes[0] != "E_MODULE" {
+		t.Fatalf("unexpected error codes %v", codes)
+	}
+	if sum.LastStatus != StatusDone || sum.Progress != 100 {
+		t.Fatalf("unexpected summary %+v", sum)
+	}
+}
+
+func TestReaderCustomRequiredFields(t *testing.T) {
+	rd := Reader{RequiredFields: []string{"status", "task_id"}}
+	sum, err := rd.Consume(strings.NewReader(`{"task_id":"T1","status":"start"}`))
+	if err != nil {
+		t.Fatalf("consume: %v", err)
+	}
+	if !sum.Conforming() {
+		t.Fatalf("unexpected violations %+v", sum.Violations)
+	}
+}